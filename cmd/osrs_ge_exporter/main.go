@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/MacroPower/osrs_ge_exporter/internal/collector"
@@ -12,16 +14,57 @@ import (
 
 	"github.com/alecthomas/kong"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const appName = "osrs_ge_exporter"
 
 var cli struct {
-	Address     string        `help:"Address to listen on for metrics." env:"ADDRESS" default:":8080"`
-	MetricsPath string        `help:"Path under which to expose metrics." env:"METRICS_PATH" default:"/metrics"`
-	Timeout     time.Duration `help:"HTTP timeout." type:"time.Duration" env:"TIMEOUT" default:"30s"`
-	Log         struct {
+	Version versionFlag `help:"Print version information and exit."`
+
+	Address       string        `help:"Address to listen on for metrics." env:"ADDRESS" default:":8080"`
+	MetricsPath   string        `help:"Path under which to expose metrics." env:"METRICS_PATH" default:"/metrics"`
+	TelemetryPath string        `help:"Separate path under which to expose exporter metrics." env:"TELEMETRY_PATH"`
+	Timeout       time.Duration `help:"HTTP and refresh timeout." type:"time.Duration" env:"TIMEOUT" default:"30s"`
+	Refresh       struct {
+		Interval        time.Duration `help:"Interval between price refreshes." type:"time.Duration" default:"1m"`
+		MappingInterval time.Duration `help:"Minimum interval between mapping refreshes." type:"time.Duration" default:"1h"`
+		MaxStaleness    time.Duration `help:"Maximum age of served price data." type:"time.Duration" default:"15m"`
+	} `prefix:"refresh." embed:""`
+	Item struct {
+		Labels           []string `help:"Item metric labels." enum:"id,name,slug,members,icon,wiki_url" default:"id"`
+		LatestTimestamps bool     `help:"Expose latest prices with the time they were traded."`
+	} `prefix:"item." embed:""`
+	Filter struct {
+		IncludeIDs   []int    `help:"Only export these item IDs." name:"include-ids"`
+		IncludeNames []string `help:"Only export items with these exact names." sep:"none"`
+		IncludeRegex string   `help:"Only export items with names matching this regex."`
+		ExcludeIDs   []int    `help:"Never export these item IDs." name:"exclude-ids"`
+		ExcludeNames []string `help:"Never export items with these exact names." sep:"none"`
+		ExcludeRegex string   `help:"Never export items with names matching this regex."`
+		Members      string   `help:"Export only members or f2p items." enum:"all,members,f2p" default:"all"`
+		MinVolume1h  int      `help:"Minimum 1h volume. Items without 1h data are excluded." name:"min-volume-1h"`
+		MinPrice     int      `help:"Minimum latest instant-buy price. Items without a latest price are excluded."`
+	} `prefix:"filter." embed:""`
+	Tax struct {
+		Rates     []string `help:"Tax rates as since:rate[:cap], e.g. 2025-05-29:0.02:5000000. Defaults to GE history."`
+		ExemptIDs []int    `help:"Item IDs exempt from tax. Defaults to the GE exemptions." name:"exempt-ids"`
+	} `prefix:"tax." embed:""`
+	Alch struct {
+		RuneItem     int     `help:"Item ID of the rune used for high alchemy." default:"561"`
+		CastsPerHour float64 `help:"Number of high alchemy casts per hour." default:"1200"`
+	} `prefix:"alch." embed:""`
+	Client struct {
+		Retries    int           `help:"Maximum number of retries for failed upstream requests." default:"2"`
+		MinBackoff time.Duration `help:"Delay before the first retry." type:"time.Duration" default:"500ms"`
+		MaxBackoff time.Duration `help:"Maximum delay between retries." type:"time.Duration" default:"5s"`
+	} `prefix:"client." embed:""`
+	Collector struct {
+		Go      bool `help:"Export Go runtime metrics of the exporter."`
+		Process bool `help:"Export process metrics of the exporter."`
+	} `prefix:"collector." embed:""`
+	Log struct {
 		Level  string `help:"Log level." default:"info"`
 		Format string `help:"Log format. One of: [logfmt, json]" default:"logfmt"`
 	} `prefix:"log." embed:""`
@@ -30,6 +73,13 @@ var cli struct {
 func main() {
 	cliCtx := kong.Parse(&cli, kong.Name(appName))
 
+	includeRegex, err := compileRegex(cli.Filter.IncludeRegex)
+	cliCtx.FatalIfErrorf(err)
+	excludeRegex, err := compileRegex(cli.Filter.ExcludeRegex)
+	cliCtx.FatalIfErrorf(err)
+	taxRates, err := parseTaxRates(cli.Tax.Rates)
+	cliCtx.FatalIfErrorf(err)
+
 	logLevel := &log.AllowedLevel{}
 	if err := logLevel.Set(cli.Log.Level); err != nil {
 		cliCtx.FatalIfErrorf(err)
@@ -45,7 +95,7 @@ func main() {
 		Format: logFormat,
 	})
 
-	err := log.Info(logger).Log("msg", fmt.Sprintf("Starting %s", appName))
+	err = log.Info(logger).Log("msg", fmt.Sprintf("Starting %s", appName))
 	cliCtx.FatalIfErrorf(err)
 	err = version.LogInfo(logger)
 	cliCtx.FatalIfErrorf(err)
@@ -54,17 +104,84 @@ func main() {
 
 	mux := http.NewServeMux()
 
-	c := client.NewOSRSPriceClient()
-	metricExporter := collector.NewExporter(c, cli.Timeout, logger)
-	prometheus.MustRegister(metricExporter)
-	mux.Handle(cli.MetricsPath, promhttp.Handler())
+	c := client.NewOSRSPriceClient(
+		client.WithHTTPClient(&http.Client{Timeout: cli.Timeout}),
+		client.WithRetry(client.RetryConfig{
+			MaxRetries: cli.Client.Retries,
+			MinBackoff: cli.Client.MinBackoff,
+			MaxBackoff: cli.Client.MaxBackoff,
+		}),
+	)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c, version.NewCollector(appName))
+	if cli.Collector.Go {
+		reg.MustRegister(collectors.NewGoCollector())
+	}
+	if cli.Collector.Process {
+		reg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
 
+	metricExporter := collector.NewExporter(c, &collector.Config{
+		Timeout:                cli.Timeout,
+		RefreshInterval:        cli.Refresh.Interval,
+		MappingRefreshInterval: cli.Refresh.MappingInterval,
+		MaxStaleness:           cli.Refresh.MaxStaleness,
+		Labels:                 cli.Item.Labels,
+		LatestTimestamps:       cli.Item.LatestTimestamps,
+		Filter: collector.ItemFilter{
+			IncludeIDs:   cli.Filter.IncludeIDs,
+			IncludeNames: cli.Filter.IncludeNames,
+			IncludeRegex: includeRegex,
+			ExcludeIDs:   cli.Filter.ExcludeIDs,
+			ExcludeNames: cli.Filter.ExcludeNames,
+			ExcludeRegex: excludeRegex,
+			Members:      cli.Filter.Members,
+			MinVolume1h:  cli.Filter.MinVolume1h,
+			MinPrice:     cli.Filter.MinPrice,
+		},
+		Tax: collector.TaxModel{
+			Rates:     taxRates,
+			ExemptIDs: cli.Tax.ExemptIDs,
+		},
+		AlchRuneID:       cli.Alch.RuneItem,
+		AlchCastsPerHour: cli.Alch.CastsPerHour,
+	}, logger)
+	reg.MustRegister(metricExporter.Telemetry())
+
+	// The exporter's own metrics are served with the item metrics, unless a
+	// separate telemetry path is configured.
+	// Requests to each path are counted separately, by a path label.
+	instrument := func(path string, handler http.Handler) http.Handler {
+		return promhttp.InstrumentMetricHandler(
+			prometheus.WrapRegistererWith(prometheus.Labels{"path": path}, reg), handler,
+		)
+	}
+	var gatherer prometheus.Gatherer = reg
+	separateTelemetry := cli.TelemetryPath != "" && cli.TelemetryPath != cli.MetricsPath
+	if separateTelemetry {
+		gatherer = prometheus.Gatherers{}
+		mux.Handle(cli.TelemetryPath, instrument(cli.TelemetryPath, promhttp.HandlerFor(reg, promhttp.HandlerOpts{})))
+	}
+	mux.Handle(cli.MetricsPath, instrument(
+		cli.MetricsPath, collector.NewHandler(metricExporter, gatherer, promhttp.HandlerOpts{}),
+	))
+
+	mux.Handle("/version", version.Handler())
+
+	go metricExporter.Run(context.Background())
+
+	telemetryLink := ""
+	if separateTelemetry {
+		telemetryLink = `<p><a href="` + cli.TelemetryPath + `">Telemetry</a></p>`
+	}
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, err := w.Write([]byte(`<html>
 			<head><title>OSRS GE Exporter</title></head>
 			<body>
 			<h1>OSRS GE Exporter</h1>
 			<p><a href="` + cli.MetricsPath + `">Metrics</a></p>
+			` + telemetryLink + `
+			<p><a href="/version">Version</a></p>
 			</body>
 			</html>`))
 		if err != nil {
@@ -86,3 +203,47 @@ func main() {
 		cliCtx.Exit(1)
 	}
 }
+
+// versionFlag prints the version and build context as JSON and exits.
+type versionFlag bool
+
+func (versionFlag) BeforeApply(app *kong.Kong) error {
+	data, err := version.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to print version: %w", err)
+	}
+	fmt.Fprintln(app.Stdout, string(data))
+	app.Exit(0)
+
+	return nil
+}
+
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil //nolint:nilnil // No regex is a valid result.
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+	}
+
+	return re, nil
+}
+
+func parseTaxRates(values []string) ([]collector.TaxRate, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	rates := make([]collector.TaxRate, 0, len(values))
+	for _, v := range values {
+		rate, err := collector.ParseTaxRate(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --tax.rates: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
@@ -1,9 +1,8 @@
 package collector
 
 import (
-	"context"
-	"fmt"
-	"sync"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/MacroPower/osrs_ge_exporter/internal/log"
@@ -18,192 +17,297 @@ const (
 )
 
 type Exporter struct {
-	ItemValue          *prometheus.GaugeVec
-	ItemHigh5m         *prometheus.GaugeVec
-	ItemLow5m          *prometheus.GaugeVec
-	ItemHighVolume5m   *prometheus.GaugeVec
-	ItemLowVolume5m    *prometheus.GaugeVec
-	ItemHigh1h         *prometheus.GaugeVec
-	ItemLow1h          *prometheus.GaugeVec
-	ItemHighVolume1h   *prometheus.GaugeVec
-	ItemLowVolume1h    *prometheus.GaugeVec
-	ItemHighLatest     *prometheus.GaugeVec
-	ItemHighLatestTime *prometheus.GaugeVec
-	ItemLowLatest      *prometheus.GaugeVec
-	ItemLowLatestTime  *prometheus.GaugeVec
-	ItemHighAlch       *prometheus.GaugeVec
-	ItemLowAlch        *prometheus.GaugeVec
-	ItemLimit          *prometheus.GaugeVec
+	ItemInfo                  *prometheus.Desc
+	ItemValue                 *prometheus.Desc
+	ItemHigh5m                *prometheus.Desc
+	ItemLow5m                 *prometheus.Desc
+	ItemHighVolume5m          *prometheus.Desc
+	ItemLowVolume5m           *prometheus.Desc
+	ItemHigh1h                *prometheus.Desc
+	ItemLow1h                 *prometheus.Desc
+	ItemHighVolume1h          *prometheus.Desc
+	ItemLowVolume1h           *prometheus.Desc
+	ItemHighLatest            *prometheus.Desc
+	ItemHighLatestTime        *prometheus.Desc
+	ItemLowLatest             *prometheus.Desc
+	ItemLowLatestTime         *prometheus.Desc
+	ItemHighLatestAge         *prometheus.Desc
+	ItemLowLatestAge          *prometheus.Desc
+	ItemHighAlch              *prometheus.Desc
+	ItemLowAlch               *prometheus.Desc
+	ItemLimit                 *prometheus.Desc
+	ItemMarginLatest          *prometheus.Desc
+	ItemSpreadLatest          *prometheus.Desc
+	ItemROILatest             *prometheus.Desc
+	ItemMargin5m              *prometheus.Desc
+	ItemSpread5m              *prometheus.Desc
+	ItemROI5m                 *prometheus.Desc
+	ItemMargin1h              *prometheus.Desc
+	ItemSpread1h              *prometheus.Desc
+	ItemROI1h                 *prometheus.Desc
+	ItemPostTaxMarginLatest   *prometheus.Desc
+	ItemPostTaxROILatest      *prometheus.Desc
+	ItemPostTaxMargin5m       *prometheus.Desc
+	ItemPostTaxROI5m          *prometheus.Desc
+	ItemPostTaxMargin1h       *prometheus.Desc
+	ItemPostTaxROI1h          *prometheus.Desc
+	ItemLimitProfit           *prometheus.Desc
+	ItemHighAlchProfit        *prometheus.Desc
+	ItemHighAlchProfitPerHour *prometheus.Desc
 
-	mu            sync.Mutex
 	up            prometheus.Gauge
 	totalScrapes  prometheus.Counter
-	queryFailures prometheus.Counter
+	queryFailures *prometheus.CounterVec
+	fetchDuration *prometheus.GaugeVec
+	refreshes     *prometheus.CounterVec
+	snapshotAge   prometheus.Gauge
+	lastSuccess   *prometheus.GaugeVec
+	endpointUp    *prometheus.GaugeVec
 
-	client  *client.PriceClient
-	timeout time.Duration
-	logger  log.Logger
+	snapshot atomic.Pointer[snapshot]
+	metrics  atomic.Pointer[metricCache]
+
+	client *client.PriceClient
+	cfg    *Config
+	filter *itemFilter
+	tax    *taxModel
+	labels []string
+	logger log.Logger
+}
+
+// Config is a struct containing configurable settings for the exporter.
+type Config struct {
+	// Timeout bounds each refresh of the market data.
+	Timeout time.Duration
+	// RefreshInterval is the time between refreshes of the prices.
+	RefreshInterval time.Duration
+	// MappingRefreshInterval is the minimum time between refreshes of the
+	// item mapping.
+	MappingRefreshInterval time.Duration
+	// MaxStaleness is the maximum age of price data that is still served
+	// when refreshes fail. Older series are dropped. Zero disables the limit.
+	MaxStaleness time.Duration
+	// Filter selects the items that metrics are exported for.
+	Filter ItemFilter
+	// Labels are the labels attached to item metrics, see ItemLabels. The id
+	// label is always attached. All labels, and the examine text, are
+	// available from the item info metric. Labels not in ItemLabels are
+	// ignored. Defaults to DefaultItemLabels.
+	Labels []string
+	// Tax is the Grand Exchange tax model used for post-tax metrics.
+	Tax TaxModel
+	// AlchRuneID is the item ID of the rune consumed by each high alchemy
+	// cast. Defaults to the nature rune.
+	AlchRuneID int
+	// AlchCastsPerHour is the number of high alchemy casts per hour.
+	// Defaults to 1200.
+	AlchCastsPerHour float64
+	// LatestTimestamps exposes the latest prices with the time they were
+	// traded as the sample timestamp, instead of the scrape time.
+	LatestTimestamps bool
 }
 
-// NewExporter creates an Exporter.
-func NewExporter(client *client.PriceClient, timeout time.Duration, logger log.Logger) *Exporter {
-	labels := []string{
-		"name",
-		"id",
-		"members",
-		"icon",
+// NewExporter creates an Exporter. Market data is only fetched by Refresh or
+// Run; Collect serves the most recent snapshot.
+func NewExporter(client *client.PriceClient, cfg *Config, logger log.Logger) *Exporter {
+	for _, name := range cfg.Labels {
+		if !slices.Contains(ItemLabels, name) {
+			log.Warn(logger).Log("msg", "Ignoring unknown item label", "label", name)
+		}
 	}
+	labels := itemLabelNames(cfg.Labels)
 
 	return &Exporter{
-		ItemValue: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_value",
-				Help:      "Current value of an item.",
-			},
-			labels,
-		),
-		ItemHigh5m: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_high_5m",
-				Help:      "High value of an item (5m avg).",
-			},
-			labels,
-		),
-		ItemLow5m: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_low_5m",
-				Help:      "Low value of an item (5m avg).",
-			},
-			labels,
-		),
-		ItemHighVolume5m: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_high_volume_5m",
-				Help:      "Traded volume of an item (5m).",
-			},
-			labels,
-		),
-		ItemLowVolume5m: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_low_volume_5m",
-				Help:      "Traded volume of an item (5m).",
-			},
-			labels,
-		),
-		ItemHigh1h: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_high_1h",
-				Help:      "High value of an item (1h avg).",
-			},
-			labels,
-		),
-		ItemLow1h: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_low_1h",
-				Help:      "Low value of an item (1h avg).",
-			},
-			labels,
-		),
-		ItemHighVolume1h: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_high_volume_1h",
-				Help:      "Traded volume of an item (1h).",
-			},
-			labels,
-		),
-		ItemLowVolume1h: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_low_volume_1h",
-				Help:      "Traded volume of an item (1h).",
-			},
-			labels,
-		),
-		ItemHighLatest: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_high_latest",
-				Help:      "High value of an item (latest).",
-			},
-			labels,
-		),
-		ItemHighLatestTime: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_high_latest_time",
-				Help:      "Unix timestamp of the latest transaction.",
-			},
-			labels,
-		),
-		ItemLowLatest: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_low_latest",
-				Help:      "Low value of an item (latest).",
-			},
-			labels,
-		),
-		ItemLowLatestTime: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_low_latest_time",
-				Help:      "Unix timestamp of the latest transaction.",
-			},
-			labels,
-		),
-		ItemHighAlch: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_high_alch",
-				Help:      "High alch value of an item.",
-			},
-			labels,
-		),
-		ItemLowAlch: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_low_alch",
-				Help:      "Low alch value of an item.",
-			},
-			labels,
-		),
-		ItemLimit: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: subsystem,
-				Name:      "item_limit",
-				Help:      "Buy limit for an item.",
-			},
-			labels,
+		ItemInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_info"),
+			"Metadata of an item. Always 1.",
+			infoLabels, nil,
+		),
+		ItemValue: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_value"),
+			"Current value of an item.",
+			labels, nil,
+		),
+		ItemHigh5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_5m"),
+			"High value of an item (5m avg).",
+			labels, nil,
+		),
+		ItemLow5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_low_5m"),
+			"Low value of an item (5m avg).",
+			labels, nil,
+		),
+		ItemHighVolume5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_volume_5m"),
+			"Traded volume of an item (5m).",
+			labels, nil,
+		),
+		ItemLowVolume5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_low_volume_5m"),
+			"Traded volume of an item (5m).",
+			labels, nil,
+		),
+		ItemHigh1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_1h"),
+			"High value of an item (1h avg).",
+			labels, nil,
+		),
+		ItemLow1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_low_1h"),
+			"Low value of an item (1h avg).",
+			labels, nil,
+		),
+		ItemHighVolume1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_volume_1h"),
+			"Traded volume of an item (1h).",
+			labels, nil,
+		),
+		ItemLowVolume1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_low_volume_1h"),
+			"Traded volume of an item (1h).",
+			labels, nil,
+		),
+		ItemHighLatest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_latest"),
+			"High value of an item (latest).",
+			labels, nil,
+		),
+		ItemHighLatestTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_latest_time"),
+			"Unix timestamp of the latest transaction.",
+			labels, nil,
+		),
+		ItemLowLatest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_low_latest"),
+			"Low value of an item (latest).",
+			labels, nil,
+		),
+		ItemLowLatestTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_low_latest_time"),
+			"Unix timestamp of the latest transaction.",
+			labels, nil,
+		),
+		ItemHighLatestAge: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_latest_age_seconds"),
+			"Seconds since the latest high value of an item was traded.",
+			labels, nil,
+		),
+		ItemLowLatestAge: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_low_latest_age_seconds"),
+			"Seconds since the latest low value of an item was traded.",
+			labels, nil,
+		),
+		ItemHighAlch: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_alch"),
+			"High alch value of an item.",
+			labels, nil,
+		),
+		ItemLowAlch: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_low_alch"),
+			"Low alch value of an item.",
+			labels, nil,
+		),
+		ItemLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_limit"),
+			"Buy limit for an item.",
+			labels, nil,
+		),
+		ItemMarginLatest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_margin_latest"),
+			"Difference between the high and low value of an item (latest).",
+			labels, nil,
+		),
+		ItemSpreadLatest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_spread_ratio_latest"),
+			"Margin of an item relative to its mid value (latest).",
+			labels, nil,
+		),
+		ItemROILatest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_roi_ratio_latest"),
+			"Margin of an item relative to its low value (latest).",
+			labels, nil,
+		),
+		ItemMargin5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_margin_5m"),
+			"Difference between the high and low value of an item (5m avg).",
+			labels, nil,
+		),
+		ItemSpread5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_spread_ratio_5m"),
+			"Margin of an item relative to its mid value (5m avg).",
+			labels, nil,
+		),
+		ItemROI5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_roi_ratio_5m"),
+			"Margin of an item relative to its low value (5m avg).",
+			labels, nil,
+		),
+		ItemMargin1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_margin_1h"),
+			"Difference between the high and low value of an item (1h avg).",
+			labels, nil,
+		),
+		ItemSpread1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_spread_ratio_1h"),
+			"Margin of an item relative to its mid value (1h avg).",
+			labels, nil,
+		),
+		ItemROI1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_roi_ratio_1h"),
+			"Margin of an item relative to its low value (1h avg).",
+			labels, nil,
+		),
+		ItemPostTaxMarginLatest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_post_tax_margin_latest"),
+			"Margin of an item after the tax on selling at the high value (latest).",
+			labels, nil,
+		),
+		ItemPostTaxROILatest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_post_tax_roi_ratio_latest"),
+			"Post-tax margin of an item relative to its low value (latest).",
+			labels, nil,
+		),
+		ItemPostTaxMargin5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_post_tax_margin_5m"),
+			"Margin of an item after the tax on selling at the high value (5m avg).",
+			labels, nil,
+		),
+		ItemPostTaxROI5m: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_post_tax_roi_ratio_5m"),
+			"Post-tax margin of an item relative to its low value (5m avg).",
+			labels, nil,
+		),
+		ItemPostTaxMargin1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_post_tax_margin_1h"),
+			"Margin of an item after the tax on selling at the high value (1h avg).",
+			labels, nil,
+		),
+		ItemPostTaxROI1h: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_post_tax_roi_ratio_1h"),
+			"Post-tax margin of an item relative to its low value (1h avg).",
+			labels, nil,
+		),
+		ItemLimitProfit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_limit_profit_latest"),
+			"Post-tax profit of flipping an item's buy limit, capped by its 1h volume (latest).",
+			labels, nil,
+		),
+		ItemHighAlchProfit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_alch_profit"),
+			"Profit of buying an item and a rune, and casting high alchemy on it.",
+			labels, nil,
+		),
+		ItemHighAlchProfitPerHour: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "item_high_alch_profit_per_hour"),
+			"Profit of casting high alchemy on an item for an hour.",
+			labels, nil,
 		),
 		up: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
 			Name:      "up",
-			Help:      "Was the last scrape successful.",
+			Help:      "Was the last refresh of all endpoints successful.",
 		}),
 		totalScrapes: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
@@ -211,163 +315,166 @@ func NewExporter(client *client.PriceClient, timeout time.Duration, logger log.L
 			Name:      "exporter_scrapes_total",
 			Help:      "Number of scrapes.",
 		}),
-		queryFailures: prometheus.NewCounter(prometheus.CounterOpts{
+		queryFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
 			Name:      "exporter_query_failures_total",
-			Help:      "Number of errors.",
+			Help:      "Number of errors, by endpoint and reason.",
+		}, []string{"endpoint", "reason"}),
+		fetchDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "exporter_fetch_duration_seconds",
+			Help:      "Duration of the last fetch from each endpoint.",
+		}, []string{"endpoint"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "exporter_refreshes_total",
+			Help:      "Number of market data refreshes, by result.",
+		}, []string{"result"}),
+		snapshotAge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "exporter_snapshot_age_seconds",
+			Help:      "Time since the served snapshot was published.",
 		}),
-		client:  client,
-		timeout: timeout,
-		logger:  logger,
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "endpoint_last_success_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful fetch from each endpoint.",
+		}, []string{"endpoint"}),
+		endpointUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "endpoint_up",
+			Help:      "Was the last fetch from each endpoint successful.",
+		}, []string{"endpoint"}),
+		client: client,
+		cfg:    cfg,
+		filter: newItemFilter(&cfg.Filter),
+		tax:    newTaxModel(&cfg.Tax),
+		labels: labels,
+		logger: logger,
 	}
 }
 
-// Describe describes all metrics with constant descriptions.
+// Describe describes all metrics the exporter can collect, including item
+// metrics that are only collected when the data they are derived from is
+// available.
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range e.itemDescs() {
+		ch <- desc
+	}
+	e.describeTelemetry(ch)
+}
+
+func (e *Exporter) describeTelemetry(ch chan<- *prometheus.Desc) {
 	ch <- e.up.Desc()
 	ch <- e.totalScrapes.Desc()
-	ch <- e.queryFailures.Desc()
+	e.queryFailures.Describe(ch)
+	e.fetchDuration.Describe(ch)
+	e.refreshes.Describe(ch)
+	ch <- e.snapshotAge.Desc()
+	e.lastSuccess.Describe(ch)
+	e.endpointUp.Describe(ch)
 }
 
-// Collect sets and collects all metrics.
-func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
-	e.mu.Lock() // To protect metrics from concurrent collects.
-	defer e.mu.Unlock()
-
-	e.ItemValue.Reset()
-	e.ItemHigh5m.Reset()
-	e.ItemLow5m.Reset()
-	e.ItemHighVolume5m.Reset()
-	e.ItemLowVolume5m.Reset()
-	e.ItemHigh1h.Reset()
-	e.ItemLow1h.Reset()
-	e.ItemHighVolume1h.Reset()
-	e.ItemLowVolume1h.Reset()
-	e.ItemHighLatest.Reset()
-	e.ItemHighLatestTime.Reset()
-	e.ItemLowLatest.Reset()
-	e.ItemLowLatestTime.Reset()
-	e.ItemHighAlch.Reset()
-	e.ItemLowAlch.Reset()
-	e.ItemLimit.Reset()
-
-	up := float64(1)
-	err := e.scrape()
-	if err != nil {
-		up = float64(0)
-		e.queryFailures.Inc()
-		log.Error(e.logger).Log("msg", "Collection failed", "err", err)
+// itemDescs returns the descriptions of all item metrics.
+func (e *Exporter) itemDescs() []*prometheus.Desc {
+	return []*prometheus.Desc{
+		e.ItemInfo,
+		e.ItemValue,
+		e.ItemHigh5m,
+		e.ItemLow5m,
+		e.ItemHighVolume5m,
+		e.ItemLowVolume5m,
+		e.ItemHigh1h,
+		e.ItemLow1h,
+		e.ItemHighVolume1h,
+		e.ItemLowVolume1h,
+		e.ItemHighLatest,
+		e.ItemHighLatestTime,
+		e.ItemLowLatest,
+		e.ItemLowLatestTime,
+		e.ItemHighLatestAge,
+		e.ItemLowLatestAge,
+		e.ItemHighAlch,
+		e.ItemLowAlch,
+		e.ItemLimit,
+		e.ItemMarginLatest,
+		e.ItemSpreadLatest,
+		e.ItemROILatest,
+		e.ItemMargin5m,
+		e.ItemSpread5m,
+		e.ItemROI5m,
+		e.ItemMargin1h,
+		e.ItemSpread1h,
+		e.ItemROI1h,
+		e.ItemPostTaxMarginLatest,
+		e.ItemPostTaxROILatest,
+		e.ItemPostTaxMargin5m,
+		e.ItemPostTaxROI5m,
+		e.ItemPostTaxMargin1h,
+		e.ItemPostTaxROI1h,
+		e.ItemLimitProfit,
+		e.ItemHighAlchProfit,
+		e.ItemHighAlchProfitPerHour,
 	}
-	e.up.Set(up)
+}
+
+// Collect collects all metrics from the current snapshot, and the exporter's
+// own telemetry.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	e.totalScrapes.Inc()
+	e.collect(ch, &selection{})
+	e.collectTelemetry(ch)
+}
 
-	e.ItemValue.Collect(ch)
-	e.ItemHigh5m.Collect(ch)
-	e.ItemLow5m.Collect(ch)
-	e.ItemHighVolume5m.Collect(ch)
-	e.ItemLowVolume5m.Collect(ch)
-	e.ItemHigh1h.Collect(ch)
-	e.ItemLow1h.Collect(ch)
-	e.ItemHighVolume1h.Collect(ch)
-	e.ItemLowVolume1h.Collect(ch)
-	e.ItemHighLatest.Collect(ch)
-	e.ItemHighLatestTime.Collect(ch)
-	e.ItemLowLatest.Collect(ch)
-	e.ItemLowLatestTime.Collect(ch)
-	e.ItemHighAlch.Collect(ch)
-	e.ItemLowAlch.Collect(ch)
-	e.ItemLimit.Collect(ch)
+// collect collects the item metrics of the selected items and endpoints. Item
+// metrics are built once per snapshot and shared by concurrent collects, see
+// itemMetrics. The caller counts the scrape, before the telemetry is gathered.
+func (e *Exporter) collect(ch chan<- prometheus.Metric, sel *selection) {
+	if snap := e.snapshot.Load(); snap != nil {
+		now := time.Now()
+		e.collectItems(ch, e.itemMetrics(snap.fresh(now, e.cfg.MaxStaleness)), sel, now)
+	}
+}
 
+func (e *Exporter) collectTelemetry(ch chan<- prometheus.Metric) {
 	ch <- e.up
 	ch <- e.totalScrapes
-	ch <- e.queryFailures
-}
-
-func (e *Exporter) scrape() error {
-	ctx := context.Background()
-	mapping, err := e.client.GetMapping(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to get mapping: %w", err)
-	}
-	avg5m, err := e.client.Get5m(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to get 5m avg: %w", err)
+	e.queryFailures.Collect(ch)
+	e.fetchDuration.Collect(ch)
+	e.refreshes.Collect(ch)
+	if snap := e.snapshot.Load(); snap != nil {
+		e.snapshotAge.Set(time.Since(snap.time).Seconds())
+		ch <- e.snapshotAge
 	}
-	avg1h, err := e.client.Get1h(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to get 1h avg: %w", err)
-	}
-	latest, err := e.client.GetLatest(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to get latest: %w", err)
-	}
-
-	for _, item := range mapping {
-		labels := []string{
-			item.Name,
-			fmt.Sprint(item.ID),
-			boolToString(item.Members),
-			item.Icon,
-		}
-		e.ItemValue.WithLabelValues(labels...).Set(float64(item.Value))
-		if item.Highalch != nil {
-			e.ItemHighAlch.WithLabelValues(labels...).Set(float64(*item.Highalch))
-		}
-		if item.Lowalch != nil {
-			e.ItemLowAlch.WithLabelValues(labels...).Set(float64(*item.Lowalch))
-		}
-		if item.Limit != nil {
-			e.ItemLimit.WithLabelValues(labels...).Set(float64(*item.Limit))
-		}
+	e.lastSuccess.Collect(ch)
+	e.endpointUp.Collect(ch)
+}
 
-		if avgItem, ok := avg5m.Data[fmt.Sprint(item.ID)]; ok {
-			if avgItem.AvgHighPrice != nil {
-				e.ItemHigh5m.WithLabelValues(labels...).Set(float64(*avgItem.AvgHighPrice))
-			}
-			if avgItem.AvgLowPrice != nil {
-				e.ItemLow5m.WithLabelValues(labels...).Set(float64(*avgItem.AvgLowPrice))
-			}
-			if avgItem.HighPriceVolume != nil {
-				e.ItemHighVolume5m.WithLabelValues(labels...).Set(float64(*avgItem.HighPriceVolume))
-			}
-			if avgItem.LowPriceVolume != nil {
-				e.ItemLowVolume5m.WithLabelValues(labels...).Set(float64(*avgItem.LowPriceVolume))
-			}
-		}
+// Telemetry returns a collector of only the exporter's own metrics, such as
+// the outcome of refreshes, without any item metrics. It must not be
+// registered alongside the Exporter itself.
+func (e *Exporter) Telemetry() prometheus.Collector { //nolint:ireturn // Hides the collector type.
+	return &telemetryCollector{e: e}
+}
 
-		if avgItem, ok := avg1h.Data[fmt.Sprint(item.ID)]; ok {
-			if avgItem.AvgHighPrice != nil {
-				e.ItemHigh1h.WithLabelValues(labels...).Set(float64(*avgItem.AvgHighPrice))
-			}
-			if avgItem.AvgLowPrice != nil {
-				e.ItemLow1h.WithLabelValues(labels...).Set(float64(*avgItem.AvgLowPrice))
-			}
-			if avgItem.HighPriceVolume != nil {
-				e.ItemHighVolume1h.WithLabelValues(labels...).Set(float64(*avgItem.HighPriceVolume))
-			}
-			if avgItem.LowPriceVolume != nil {
-				e.ItemLowVolume1h.WithLabelValues(labels...).Set(float64(*avgItem.LowPriceVolume))
-			}
-		}
+type telemetryCollector struct {
+	e *Exporter
+}
 
-		if latestItem, ok := latest.Data[fmt.Sprint(item.ID)]; ok {
-			if latestItem.High != nil {
-				e.ItemHighLatest.WithLabelValues(labels...).Set(float64(*latestItem.High))
-			}
-			if latestItem.Low != nil {
-				e.ItemLowLatest.WithLabelValues(labels...).Set(float64(*latestItem.Low))
-			}
-			if latestItem.HighTime != nil {
-				e.ItemHighLatestTime.WithLabelValues(labels...).Set(float64(*latestItem.HighTime))
-			}
-			if latestItem.LowTime != nil {
-				e.ItemLowLatestTime.WithLabelValues(labels...).Set(float64(*latestItem.LowTime))
-			}
-		}
-	}
+// Describe implements [prometheus.Collector].
+func (c *telemetryCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.e.describeTelemetry(ch)
+}
 
-	return nil
+// Collect implements [prometheus.Collector].
+func (c *telemetryCollector) Collect(ch chan<- prometheus.Metric) {
+	c.e.collectTelemetry(ch)
 }
 
 func boolToString(b bool) string {
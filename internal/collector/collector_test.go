@@ -0,0 +1,452 @@
+package collector_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/collector"
+	"github.com/MacroPower/osrs_ge_exporter/internal/log"
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const natureRuneID = 561
+
+// newTestExporter returns an exporter that has been refreshed from a fake
+// prices API serving n items, with IDs 1 to n, and the nature rune.
+func newTestExporter(tb testing.TB, n int, cfg *collector.Config) *collector.Exporter {
+	tb.Helper()
+
+	e := newFakeAPI(tb, n).exporter(tb, cfg)
+	if err := e.Refresh(context.Background()); err != nil {
+		tb.Fatal(err)
+	}
+
+	return e
+}
+
+// fakeAPI is a fake prices API. It counts the requests to each endpoint, and
+// can be made to fail or delay requests to an endpoint.
+type fakeAPI struct {
+	srv    *httptest.Server
+	bodies map[string]any
+
+	mu     sync.Mutex
+	hits   map[string]int
+	fails  map[string]bool
+	delays map[string]time.Duration
+}
+
+// newFakeAPI returns a fake prices API serving n items, with IDs 1 to n, and
+// the nature rune.
+func newFakeAPI(tb testing.TB, n int) *fakeAPI {
+	tb.Helper()
+
+	now := int(time.Now().Unix())
+	mapping := make([]map[string]any, 0, n)
+	avg := map[string]any{}
+	latest := map[string]any{}
+	ids := make([]int, 0, n+1)
+	for i := 1; i <= n; i++ {
+		ids = append(ids, i)
+	}
+	if n < natureRuneID {
+		ids = append(ids, natureRuneID)
+	}
+	for _, i := range ids {
+		id := strconv.Itoa(i)
+		mapping = append(mapping, map[string]any{
+			"id": i, "name": "Item " + id, "examine": "An item.", "members": i%2 == 0, "icon": "Item.png",
+			"value": i * 10, "highalch": i * 6, "lowalch": i * 4, "limit": 100,
+		})
+		avg[id] = map[string]any{"avgHighPrice": i * 120, "avgLowPrice": i * 100, "highPriceVolume": 5, "lowPriceVolume": 7}
+		latest[id] = map[string]any{"high": i * 120, "low": i * 100, "highTime": now - 60, "lowTime": now - 120}
+	}
+
+	f := &fakeAPI{
+		bodies: map[string]any{
+			"mapping": mapping,
+			"5m":      map[string]any{"data": avg, "timestamp": now - now%300},
+			"1h":      map[string]any{"data": avg, "timestamp": now - now%3600},
+			"latest":  map[string]any{"data": latest},
+		},
+		hits:   map[string]int{},
+		fails:  map[string]bool{},
+		delays: map[string]time.Duration{},
+	}
+	f.srv = httptest.NewServer(f.handler(tb))
+	tb.Cleanup(f.srv.Close)
+
+	return f
+}
+
+func (f *fakeAPI) handler(tb testing.TB) http.HandlerFunc {
+	tb.Helper()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.TrimPrefix(r.URL.Path, "/")
+		f.mu.Lock()
+		f.hits[endpoint]++
+		fail := f.fails[endpoint]
+		delay := f.delays[endpoint]
+		f.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		body, ok := f.bodies[endpoint]
+		if !ok {
+			http.NotFound(w, r)
+
+			return
+		}
+		if fail {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+
+			return
+		}
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			tb.Error(err)
+		}
+	}
+}
+
+// exporter returns a new exporter using the fake API, without refreshing it.
+func (f *fakeAPI) exporter(tb testing.TB, cfg *collector.Config) *collector.Exporter {
+	tb.Helper()
+
+	c := client.NewPriceClient(client.NewClient(f.srv.URL, f.srv.Client()))
+
+	return collector.NewExporter(c, cfg, log.New(&log.Config{}))
+}
+
+// setItem replaces the data of an item served by the given price endpoint.
+// It must be called before the endpoint is requested.
+func (f *fakeAPI) setItem(endpoint, id string, data map[string]any) {
+	body := f.bodies[endpoint].(map[string]any) //nolint:forcetypeassert // Test data.
+	items := map[string]any{}
+	for k, v := range body["data"].(map[string]any) { //nolint:forcetypeassert // Test data.
+		items[k] = v
+	}
+	items[id] = data
+
+	// The 5m and 1h endpoints share their data, so it is copied rather than
+	// modified in place.
+	copied := map[string]any{"data": items}
+	if ts, ok := body["timestamp"]; ok {
+		copied["timestamp"] = ts
+	}
+	f.bodies[endpoint] = copied
+}
+
+// fail makes requests to the given endpoint fail, or succeed again.
+func (f *fakeAPI) fail(endpoint string, fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fails[endpoint] = fail
+}
+
+// delay delays responses from the given endpoint, unless the request is
+// canceled first.
+func (f *fakeAPI) delay(endpoint string, delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.delays[endpoint] = delay
+}
+
+// requests returns the number of requests to the given endpoint.
+func (f *fakeAPI) requests(endpoint string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.hits[endpoint]
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	e := newTestExporter(t, 3, &collector.Config{})
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(e)
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{
+		"osrs_ge_item_high_latest":            240,
+		"osrs_ge_item_margin_latest":          40,
+		"osrs_ge_item_roi_ratio_1h":           0.2,
+		"osrs_ge_item_spread_ratio_latest":    40.0 / 220,
+		"osrs_ge_item_spread_ratio_5m":        40.0 / 220,
+		"osrs_ge_item_post_tax_margin_5m":     36,
+		"osrs_ge_item_limit_profit_latest":    432,
+		"osrs_ge_item_high_volume_1h":         5,
+		"osrs_ge_item_value":                  20,
+		"osrs_ge_exporter_refreshes_total":    1,
+		"osrs_ge_endpoint_up":                 1,
+		"osrs_ge_exporter_scrapes_total":      1,
+		"osrs_ge_item_info":                   1,
+		"osrs_ge_item_high_alch_profit":       12 - 200 - 56100,
+		"osrs_ge_item_low_latest_age_seconds": 120,
+	}
+	for _, mf := range mfs {
+		v, ok := want[mf.GetName()]
+		if !ok {
+			continue
+		}
+		delete(want, mf.GetName())
+
+		m := findMetric(mf, "2")
+		if m == nil {
+			t.Errorf("%s: no metric for item 2", mf.GetName())
+
+			continue
+		}
+		got := metricValue(m)
+		if mf.GetName() == "osrs_ge_item_low_latest_age_seconds" {
+			// The age increases while the test runs.
+			if got < v || got > v+60 {
+				t.Errorf("%s: expected about %v, got %v", mf.GetName(), v, got)
+			}
+
+			continue
+		}
+		if got != v {
+			t.Errorf("%s: expected %v, got %v", mf.GetName(), v, got)
+		}
+	}
+	for name := range want {
+		t.Errorf("%s: not collected", name)
+	}
+}
+
+func TestLatestTimestamps(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{false, true} {
+		enabled := enabled
+		t.Run(strconv.FormatBool(enabled), func(t *testing.T) {
+			t.Parallel()
+
+			api := newFakeAPI(t, 3)
+			// Item 3 has latest prices without the time they were traded.
+			api.setItem("latest", "3", map[string]any{"high": 360, "low": 300})
+			e := api.exporter(t, &collector.Config{LatestTimestamps: enabled})
+			if err := e.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			mfs := gather(t, e)
+
+			for _, side := range []struct {
+				name string
+				age  float64
+			}{
+				{name: "high", age: 60},
+				{name: "low", age: 120},
+			} {
+				price := findMetric(mfs["osrs_ge_item_"+side.name+"_latest"], "2")
+				traded := metricValue(findMetric(mfs["osrs_ge_item_"+side.name+"_latest_time"], "2"))
+				want := int64(0)
+				if enabled {
+					want = int64(traded) * 1000
+				}
+				if got := price.GetTimestampMs(); got != want {
+					t.Errorf("%s: expected timestamp %d, got %d", side.name, want, got)
+				}
+				age := metricValue(findMetric(mfs["osrs_ge_item_"+side.name+"_latest_age_seconds"], "2"))
+				if age < side.age || age > side.age+60 {
+					t.Errorf("%s: expected age of about %vs, got %vs", side.name, side.age, age)
+				}
+
+				// Without the time traded, the price has no timestamp and no age.
+				if got := findMetric(mfs["osrs_ge_item_"+side.name+"_latest"], "3").GetTimestampMs(); got != 0 {
+					t.Errorf("%s: expected no timestamp without the time traded, got %d", side.name, got)
+				}
+				for _, name := range []string{"_latest_time", "_latest_age_seconds"} {
+					m := findMetric(mfs["osrs_ge_item_"+side.name+name], "3")
+					if m != nil {
+						t.Errorf("%s%s: unexpected metric without the time traded", side.name, name)
+					}
+				}
+			}
+		})
+	}
+}
+
+// findMetric returns the metric of the given item, or the first metric if
+// the family is not labelled by item.
+func findMetric(mf *dto.MetricFamily, id string) *dto.Metric {
+	for _, m := range mf.GetMetric() {
+		labelled := false
+		for _, l := range m.GetLabel() {
+			if l.GetName() == collector.LabelID {
+				labelled = true
+				if l.GetValue() == id {
+					return m
+				}
+			}
+		}
+		if !labelled {
+			return m
+		}
+	}
+
+	return nil
+}
+
+func metricValue(m *dto.Metric) float64 {
+	if m.GetGauge() != nil {
+		return m.GetGauge().GetValue()
+	}
+
+	return m.GetCounter().GetValue()
+}
+
+// benchmarkConfigs are the configurations that collects are benchmarked with.
+var benchmarkConfigs = []struct {
+	name string
+	cfg  *collector.Config
+}{
+	{name: "default", cfg: &collector.Config{}},
+	{name: "all_labels", cfg: &collector.Config{Labels: collector.ItemLabels}},
+}
+
+// drain returns a channel that discards all metrics sent to it until closed,
+// and a channel that is closed once the metrics are discarded.
+func drain() (chan prometheus.Metric, chan struct{}) {
+	ch := make(chan prometheus.Metric, 1024)
+	done := make(chan struct{})
+	go func() {
+		for range ch { //nolint:revive // Drain the channel.
+		}
+		close(done)
+	}()
+
+	return ch, done
+}
+
+// BenchmarkCollect benchmarks collects between refreshes.
+func BenchmarkCollect(b *testing.B) {
+	for _, bc := range benchmarkConfigs {
+		bc := bc
+		b.Run(bc.name, func(b *testing.B) {
+			e := newTestExporter(b, 4000, bc.cfg)
+			ch, done := drain()
+
+			// The first collect after a refresh builds the item metrics, which
+			// is benchmarked by BenchmarkCollectAfterRefresh.
+			e.Collect(ch)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e.Collect(ch)
+			}
+			b.StopTimer()
+
+			close(ch)
+			<-done
+		})
+	}
+}
+
+// BenchmarkCollectAfterRefresh benchmarks the first collect after each
+// refresh.
+func BenchmarkCollectAfterRefresh(b *testing.B) {
+	for _, bc := range benchmarkConfigs {
+		bc := bc
+		b.Run(bc.name, func(b *testing.B) {
+			e := newTestExporter(b, 4000, bc.cfg)
+			ch, done := drain()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if err := e.Refresh(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				e.Collect(ch)
+			}
+			b.StopTimer()
+
+			close(ch)
+			<-done
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	for _, cfg := range []*collector.Config{
+		{},
+		{Labels: collector.ItemLabels, LatestTimestamps: true},
+	} {
+		// A pedantic registry fails to gather metrics that are not
+		// described, or that are inconsistent with their description.
+		reg := prometheus.NewPedanticRegistry()
+		if err := reg.Register(newTestExporter(t, 3, cfg)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := reg.Gather(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	problems, err := testutil.CollectAndLint(newTestExporter(t, 3, &collector.Config{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		t.Errorf("%s: %s", p.Metric, p.Text)
+	}
+}
+
+func TestTelemetry(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(newTestExporter(t, 3, &collector.Config{}).Telemetry())
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := false
+	for _, mf := range mfs {
+		if strings.HasPrefix(mf.GetName(), "osrs_ge_item_") {
+			t.Errorf("unexpected item metric %s", mf.GetName())
+		}
+		if mf.GetName() == "osrs_ge_up" {
+			up = true
+		}
+	}
+	if !up {
+		t.Error("osrs_ge_up not collected")
+	}
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+const (
+	defaultAlchRuneID       = 561 // Nature rune
+	defaultAlchCastsPerHour = 1200
+)
+
+// prices are the high and low price of an item over a window.
+type prices struct {
+	high int
+	low  int
+	// time is when the high price was traded, which determines the tax.
+	time time.Time
+}
+
+// pricesLatest returns the latest high and low price, if both are known.
+func (i *itemData) pricesLatest() (prices, bool) {
+	if i.latest == nil || i.latest.High == nil || i.latest.Low == nil {
+		return prices{}, false
+	}
+
+	p := prices{high: *i.latest.High, low: *i.latest.Low, time: i.time}
+	if i.latest.HighTime != nil {
+		p.time = time.Unix(int64(*i.latest.HighTime), 0)
+	}
+
+	return p, true
+}
+
+// prices5m returns the 5m average high and low price, if both are known.
+func (i *itemData) prices5m() (prices, bool) {
+	return avgPrices(i.avg5m, i.time5m)
+}
+
+// prices1h returns the 1h average high and low price, if both are known.
+func (i *itemData) prices1h() (prices, bool) {
+	return avgPrices(i.avg1h, i.time1h)
+}
+
+func avgPrices(avg *client.ItemAvg, t time.Time) (prices, bool) {
+	if avg == nil || avg.AvgHighPrice == nil || avg.AvgLowPrice == nil {
+		return prices{}, false
+	}
+
+	return prices{high: *avg.AvgHighPrice, low: *avg.AvgLowPrice, time: t}, true
+}
+
+// buyPrice returns the latest low price, falling back to the 5m average low
+// price if the item has not traded recently.
+func (i *itemData) buyPrice() (int, bool) {
+	if i.latest != nil && i.latest.Low != nil {
+		return *i.latest.Low, true
+	}
+	if i.avg5m != nil && i.avg5m.AvgLowPrice != nil {
+		return *i.avg5m.AvgLowPrice, true
+	}
+
+	return 0, false
+}
+
+// limitQuantity returns the number of units that can be flipped in one buy
+// limit window: the buy limit, capped by the units traded in the last hour.
+func (i *itemData) limitQuantity() (int, bool) {
+	if i.Limit == nil || i.avg1h == nil {
+		return 0, false
+	}
+
+	return min(*i.Limit, derefInt(i.avg1h.HighPriceVolume)+derefInt(i.avg1h.LowPriceVolume)), true
+}
+
+// margin returns the difference between the high and low price.
+func (p prices) margin() int {
+	return p.high - p.low
+}
+
+// spread returns the margin relative to the mid price.
+func (p prices) spread() (float64, bool) {
+	mid := float64(p.high+p.low) / 2 //nolint:gomnd // Midpoint.
+	if mid == 0 {
+		return 0, false
+	}
+
+	return float64(p.margin()) / mid, true
+}
+
+// roi returns the margin relative to the low (buy) price.
+func (p prices) roi() (float64, bool) {
+	return p.ratio(p.margin())
+}
+
+// postTaxMargin returns the margin after the tax on selling at the high price.
+func (p prices) postTaxMargin(id int, tax *taxModel) int {
+	return p.margin() - tax.tax(id, p.high, p.time)
+}
+
+// ratio returns the given amount relative to the low (buy) price.
+func (p prices) ratio(amount int) (float64, bool) {
+	if p.low == 0 {
+		return 0, false
+	}
+
+	return float64(amount) / float64(p.low), true
+}
+
+func (e *Exporter) alchRuneID() int {
+	if e.cfg.AlchRuneID <= 0 {
+		return defaultAlchRuneID
+	}
+
+	return e.cfg.AlchRuneID
+}
+
+func (e *Exporter) alchCastsPerHour() float64 {
+	if e.cfg.AlchCastsPerHour <= 0 {
+		return defaultAlchCastsPerHour
+	}
+
+	return e.cfg.AlchCastsPerHour
+}
@@ -0,0 +1,305 @@
+package collector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/collector"
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+func TestLimitQuantity(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		limit  *int
+		avg1h  *client.ItemAvg
+		want   int
+		wantOK bool
+	}{
+		"below volume": {
+			limit:  intPtr(100),
+			avg1h:  &client.ItemAvg{HighPriceVolume: intPtr(80), LowPriceVolume: intPtr(70)},
+			want:   100,
+			wantOK: true,
+		},
+		"capped by volume": {
+			limit:  intPtr(100),
+			avg1h:  &client.ItemAvg{HighPriceVolume: intPtr(30), LowPriceVolume: intPtr(40)},
+			want:   70,
+			wantOK: true,
+		},
+		"equal to volume": {
+			limit:  intPtr(70),
+			avg1h:  &client.ItemAvg{HighPriceVolume: intPtr(30), LowPriceVolume: intPtr(40)},
+			want:   70,
+			wantOK: true,
+		},
+		"one side traded": {
+			limit:  intPtr(100),
+			avg1h:  &client.ItemAvg{LowPriceVolume: intPtr(12)},
+			want:   12,
+			wantOK: true,
+		},
+		"not traded": {
+			limit:  intPtr(100),
+			avg1h:  &client.ItemAvg{},
+			want:   0,
+			wantOK: true,
+		},
+		"no 1h data": {limit: intPtr(100), wantOK: false},
+		"no limit": {
+			avg1h:  &client.ItemAvg{HighPriceVolume: intPtr(30), LowPriceVolume: intPtr(40)},
+			wantOK: false,
+		},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := collector.LimitQuantity(client.ItemMapping{ID: 4151, Limit: tc.limit}, tc.avg1h)
+			if ok != tc.wantOK || got != tc.want {
+				t.Errorf("expected %d, %t, got %d, %t", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestLimitProfitNeeds1h(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeAPI(t, 3)
+	api.fail("1h", true)
+	e := api.exporter(t, &collector.Config{})
+	if err := e.Refresh(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	mfs := gather(t, e)
+	if _, ok := mfs["osrs_ge_item_limit_profit_latest"]; ok {
+		t.Error("unexpected limit profit without 1h data")
+	}
+	if _, ok := mfs["osrs_ge_item_margin_latest"]; !ok {
+		t.Error("expected latest margin without 1h data")
+	}
+}
+
+func TestHighAlchProfit(t *testing.T) {
+	t.Parallel()
+
+	const (
+		highAlch  = 12    // Item 2.
+		buyPrice  = 200   // Latest low price of item 2.
+		runePrice = 56100 // Latest low price of the nature rune.
+	)
+
+	tcs := map[string]struct {
+		cfg     collector.Config
+		setup   func(api *fakeAPI)
+		profit  float64
+		perHour float64
+		absent  bool
+	}{
+		"default": {
+			profit:  highAlch - buyPrice - runePrice,
+			perHour: (highAlch - buyPrice - runePrice) * 1200,
+		},
+		"casts per hour": {
+			cfg:     collector.Config{AlchCastsPerHour: 600},
+			profit:  highAlch - buyPrice - runePrice,
+			perHour: (highAlch - buyPrice - runePrice) * 600,
+		},
+		"rune item": {
+			cfg:     collector.Config{AlchRuneID: 3},
+			profit:  highAlch - buyPrice - 300,
+			perHour: (highAlch - buyPrice - 300) * 1200,
+		},
+		"5m low price": {
+			setup: func(api *fakeAPI) {
+				api.setItem("latest", "2", map[string]any{"high": 240})
+				api.setItem("5m", "2", map[string]any{"avgHighPrice": 240, "avgLowPrice": 150})
+			},
+			profit:  highAlch - 150 - runePrice,
+			perHour: (highAlch - 150 - runePrice) * 1200,
+		},
+		"rune 5m low price": {
+			setup: func(api *fakeAPI) {
+				api.setItem("latest", "561", map[string]any{})
+				api.setItem("5m", "561", map[string]any{"avgLowPrice": 50000})
+			},
+			profit:  highAlch - buyPrice - 50000,
+			perHour: (highAlch - buyPrice - 50000) * 1200,
+		},
+		"no buy price": {
+			setup: func(api *fakeAPI) {
+				api.setItem("latest", "2", map[string]any{"high": 240})
+				api.setItem("5m", "2", map[string]any{"avgHighPrice": 240})
+			},
+			absent: true,
+		},
+		"rune without price": {
+			setup: func(api *fakeAPI) {
+				api.setItem("latest", "561", map[string]any{"high": 56200})
+				api.setItem("5m", "561", map[string]any{})
+			},
+			absent: true,
+		},
+		"unknown rune item": {
+			cfg:    collector.Config{AlchRuneID: 99999},
+			absent: true,
+		},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := newFakeAPI(t, 3)
+			if tc.setup != nil {
+				tc.setup(api)
+			}
+			e := api.exporter(t, &tc.cfg)
+			if err := e.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			mfs := gather(t, e)
+
+			profit := findMetric(mfs["osrs_ge_item_high_alch_profit"], "2")
+			perHour := findMetric(mfs["osrs_ge_item_high_alch_profit_per_hour"], "2")
+			if tc.absent {
+				if profit != nil || perHour != nil {
+					t.Error("unexpected high alchemy profit")
+				}
+
+				return
+			}
+			if profit == nil || perHour == nil {
+				t.Fatal("expected high alchemy profit")
+			}
+			if got := metricValue(profit); got != tc.profit {
+				t.Errorf("expected profit %v, got %v", tc.profit, got)
+			}
+			if got := metricValue(perHour); got != tc.perHour {
+				t.Errorf("expected profit per hour %v, got %v", tc.perHour, got)
+			}
+		})
+	}
+}
+
+func TestPrices(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		high, low int
+		margin    int
+		spread    float64
+		spreadOK  bool
+		roi       float64
+		roiOK     bool
+	}{
+		"positive margin": {
+			high: 120, low: 100, margin: 20, spread: 20.0 / 110, spreadOK: true, roi: 0.2, roiOK: true,
+		},
+		"negative margin": {
+			high: 90, low: 100, margin: -10, spread: -10.0 / 95, spreadOK: true, roi: -0.1, roiOK: true,
+		},
+		"no margin": {
+			high: 100, low: 100, margin: 0, spread: 0, spreadOK: true, roi: 0, roiOK: true,
+		},
+		"zero low": {
+			high: 100, low: 0, margin: 100, spread: 2, spreadOK: true, roiOK: false,
+		},
+		"zero high": {
+			high: 0, low: 100, margin: -100, spread: -2, spreadOK: true, roi: -1, roiOK: true,
+		},
+		"zero high and low": {
+			high: 0, low: 0, margin: 0, spreadOK: false, roiOK: false,
+		},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := collector.Margin(tc.high, tc.low); got != tc.margin {
+				t.Errorf("margin: expected %d, got %d", tc.margin, got)
+			}
+			if got, ok := collector.Spread(tc.high, tc.low); ok != tc.spreadOK || got != tc.spread {
+				t.Errorf("spread: expected %v, %t, got %v, %t", tc.spread, tc.spreadOK, got, ok)
+			}
+			if got, ok := collector.ROI(tc.high, tc.low); ok != tc.roiOK || got != tc.roi {
+				t.Errorf("roi: expected %v, %t, got %v, %t", tc.roi, tc.roiOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestPricesRatio(t *testing.T) {
+	t.Parallel()
+
+	if got, ok := collector.Ratio(120, 100, 18); !ok || got != 0.18 {
+		t.Errorf("expected 0.18, got %v, %t", got, ok)
+	}
+	if got, ok := collector.Ratio(120, 100, -5); !ok || got != -0.05 {
+		t.Errorf("expected -0.05, got %v, %t", got, ok)
+	}
+	if _, ok := collector.Ratio(120, 0, 18); ok {
+		t.Error("expected no ratio without a low price")
+	}
+}
+
+func TestPricesOmitted(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeAPI(t, 3)
+	api.setItem("latest", "2", map[string]any{"high": 0, "low": 0})
+	api.setItem("latest", "3", map[string]any{"high": 250, "low": 300})
+	e := api.exporter(t, &collector.Config{})
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mfs := gather(t, e)
+
+	if m := findMetric(mfs["osrs_ge_item_margin_latest"], "2"); m == nil || metricValue(m) != 0 {
+		t.Error("expected zero margin without prices")
+	}
+	for _, name := range []string{"osrs_ge_item_spread_ratio_latest", "osrs_ge_item_roi_ratio_latest"} {
+		if findMetric(mfs[name], "2") != nil {
+			t.Errorf("%s: unexpected ratio without prices", name)
+		}
+	}
+	want := map[string]float64{
+		"osrs_ge_item_margin_latest":       -50,
+		"osrs_ge_item_spread_ratio_latest": -50.0 / 275,
+		"osrs_ge_item_roi_ratio_latest":    -50.0 / 300,
+	}
+	for name, v := range want {
+		if m := findMetric(mfs[name], "3"); m == nil || metricValue(m) != v {
+			t.Errorf("%s: expected %v for a negative margin", name, v)
+		}
+	}
+}
+
+func TestPostTaxWithoutTimestamp(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeAPI(t, 3)
+	for _, endpoint := range []string{"5m", "1h"} {
+		body := api.bodies[endpoint].(map[string]any) //nolint:forcetypeassert // Test data.
+		api.bodies[endpoint] = map[string]any{"data": body["data"]}
+	}
+	e := api.exporter(t, &collector.Config{})
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mfs := gather(t, e)
+
+	// Without a timestamp, the prices are taxed at the current rate, rather
+	// than being dated before the tax was introduced.
+	for _, name := range []string{"osrs_ge_item_post_tax_margin_5m", "osrs_ge_item_post_tax_margin_1h"} {
+		if m := findMetric(mfs[name], "2"); m == nil || metricValue(m) != 36 {
+			t.Errorf("%s: expected a taxed margin of 36, got %v", name, m)
+		}
+	}
+}
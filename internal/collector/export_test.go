@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+// MatchItem reports whether the filter matches an item with the given data.
+func MatchItem(f *ItemFilter, mapping client.ItemMapping, avg1h *client.ItemAvg, latest *client.ItemLatest) bool {
+	return newItemFilter(f).match(&itemData{ItemMapping: mapping, avg1h: avg1h, latest: latest})
+}
+
+var (
+	ItemLabelNames = itemLabelNames
+	Slugify        = slugify
+)
+
+// Tax returns the tax charged by the model when selling the given item at the
+// given price and time.
+func Tax(m *TaxModel, id, price int, t time.Time) int {
+	return newTaxModel(m).tax(id, price, t)
+}
+
+// LimitQuantity returns the number of units of an item with the given data
+// that can be flipped in one buy limit window.
+func LimitQuantity(mapping client.ItemMapping, avg1h *client.ItemAvg) (int, bool) {
+	return (&itemData{ItemMapping: mapping, avg1h: avg1h}).limitQuantity()
+}
+
+// Margin, Spread, ROI and Ratio return the derived metrics of the given high
+// and low price.
+func Margin(high, low int) int {
+	return prices{high: high, low: low}.margin()
+}
+
+func Spread(high, low int) (float64, bool) {
+	return prices{high: high, low: low}.spread()
+}
+
+func ROI(high, low int) (float64, bool) {
+	return prices{high: high, low: low}.roi()
+}
+
+func Ratio(high, low, amount int) (float64, bool) {
+	return prices{high: high, low: low}.ratio(amount)
+}
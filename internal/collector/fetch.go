@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	endpointMapping = "mapping"
+	endpoint5m      = "5m"
+	endpoint1h      = "1h"
+	endpointLatest  = "latest"
+)
+
+// endpoints lists all endpoints in the order their metrics are collected.
+var endpoints = []string{endpointMapping, endpoint5m, endpoint1h, endpointLatest}
+
+// fetchGroup fetches from several endpoints concurrently. Each fetch succeeds
+// or fails independently of the others.
+type fetchGroup struct {
+	ctx      context.Context //nolint:containedctx // Shared by the group's fetches.
+	duration *prometheus.GaugeVec
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	results map[string]error
+}
+
+func newFetchGroup(ctx context.Context, duration *prometheus.GaugeVec) *fetchGroup {
+	return &fetchGroup{
+		ctx:      ctx,
+		duration: duration,
+		results:  map[string]error{},
+	}
+}
+
+// Go calls fn in a new goroutine and records how long it took.
+func (g *fetchGroup) Go(endpoint string, fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		start := time.Now()
+		err := fn(g.ctx)
+		g.duration.WithLabelValues(endpoint).Set(time.Since(start).Seconds())
+
+		g.mu.Lock()
+		g.results[endpoint] = err
+		g.mu.Unlock()
+	}()
+}
+
+// Wait blocks until all fetches have returned, then returns the error of each
+// endpoint that was fetched, which is nil if the fetch succeeded.
+func (g *fetchGroup) Wait() map[string]error {
+	g.wg.Wait()
+
+	return g.results
+}
@@ -0,0 +1,127 @@
+package collector
+
+import "regexp"
+
+const (
+	membersOnly = "members"
+	f2pOnly     = "f2p"
+)
+
+// ItemFilter selects the items that metrics are exported for. An item is
+// exported if it matches any of the include criteria (or none are set), none
+// of the exclude criteria, and all of the remaining criteria.
+type ItemFilter struct {
+	IncludeIDs   []int
+	IncludeNames []string
+	IncludeRegex *regexp.Regexp
+
+	ExcludeIDs   []int
+	ExcludeNames []string
+	ExcludeRegex *regexp.Regexp
+
+	// Members selects only members items if set to "members", or only
+	// free-to-play items if set to "f2p". Any other value selects both.
+	Members string
+	// MinVolume1h is the minimum number of units traded in the last hour.
+	// Items without 1h data, including while it is stale, are excluded.
+	MinVolume1h int
+	// MinPrice is the minimum latest instant-buy (high) price. Items without
+	// a latest instant-buy price, including while it is stale, are excluded.
+	MinPrice int
+}
+
+type itemFilter struct {
+	includeIDs   map[int]bool
+	includeNames map[string]bool
+	includeRegex *regexp.Regexp
+	excludeIDs   map[int]bool
+	excludeNames map[string]bool
+	excludeRegex *regexp.Regexp
+	members      string
+	minVolume1h  int
+	minPrice     int
+}
+
+func newItemFilter(f *ItemFilter) *itemFilter {
+	return &itemFilter{
+		includeIDs:   toSet(f.IncludeIDs),
+		includeNames: toSet(f.IncludeNames),
+		includeRegex: f.IncludeRegex,
+		excludeIDs:   toSet(f.ExcludeIDs),
+		excludeNames: toSet(f.ExcludeNames),
+		excludeRegex: f.ExcludeRegex,
+		members:      f.Members,
+		minVolume1h:  f.MinVolume1h,
+		minPrice:     f.MinPrice,
+	}
+}
+
+// match reports whether metrics should be exported for the item.
+func (f *itemFilter) match(item *itemData) bool {
+	return f.included(item) && !f.excluded(item) && f.matchMembers(item) &&
+		f.matchVolume(item) && f.matchPrice(item)
+}
+
+func (f *itemFilter) included(item *itemData) bool {
+	if len(f.includeIDs) == 0 && len(f.includeNames) == 0 && f.includeRegex == nil {
+		return true
+	}
+
+	return f.includeIDs[item.ID] || f.includeNames[item.Name] ||
+		(f.includeRegex != nil && f.includeRegex.MatchString(item.Name))
+}
+
+func (f *itemFilter) excluded(item *itemData) bool {
+	return f.excludeIDs[item.ID] || f.excludeNames[item.Name] ||
+		(f.excludeRegex != nil && f.excludeRegex.MatchString(item.Name))
+}
+
+func (f *itemFilter) matchMembers(item *itemData) bool {
+	switch f.members {
+	case membersOnly:
+		return item.Members
+	case f2pOnly:
+		return !item.Members
+	default:
+		return true
+	}
+}
+
+func (f *itemFilter) matchVolume(item *itemData) bool {
+	if f.minVolume1h <= 0 {
+		return true
+	}
+	if item.avg1h == nil {
+		return false
+	}
+
+	return derefInt(item.avg1h.HighPriceVolume)+derefInt(item.avg1h.LowPriceVolume) >= f.minVolume1h
+}
+
+func (f *itemFilter) matchPrice(item *itemData) bool {
+	if f.minPrice <= 0 {
+		return true
+	}
+	if item.latest == nil || item.latest.High == nil {
+		return false
+	}
+
+	return *item.latest.High >= f.minPrice
+}
+
+func toSet[T comparable](values []T) map[T]bool {
+	set := make(map[T]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+
+	return set
+}
+
+func derefInt(i *int) int {
+	if i == nil {
+		return 0
+	}
+
+	return *i
+}
@@ -0,0 +1,133 @@
+package collector_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/collector"
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestItemFilter(t *testing.T) {
+	t.Parallel()
+
+	nature := client.ItemMapping{ID: 561, Name: "Nature rune", Members: false}
+	whip := client.ItemMapping{ID: 4151, Name: "Abyssal whip", Members: true}
+	avg1h := &client.ItemAvg{HighPriceVolume: intPtr(40), LowPriceVolume: intPtr(60)}
+	latest := &client.ItemLatest{High: intPtr(1500)}
+
+	tcs := map[string]struct {
+		filter collector.ItemFilter
+		item   client.ItemMapping
+		avg1h  *client.ItemAvg
+		latest *client.ItemLatest
+		want   bool
+	}{
+		"no criteria":      {item: whip, want: true},
+		"include id":       {filter: collector.ItemFilter{IncludeIDs: []int{4151}}, item: whip, want: true},
+		"include other id": {filter: collector.ItemFilter{IncludeIDs: []int{561}}, item: whip, want: false},
+		"include name": {
+			filter: collector.ItemFilter{IncludeNames: []string{"Abyssal whip"}},
+			item:   whip,
+			want:   true,
+		},
+		"include name prefix": {
+			filter: collector.ItemFilter{IncludeNames: []string{"Abyssal"}},
+			item:   whip,
+			want:   false,
+		},
+		"include any": {
+			filter: collector.ItemFilter{IncludeIDs: []int{561}, IncludeRegex: regexp.MustCompile("whip$")},
+			item:   whip,
+			want:   true,
+		},
+		"include regex": {
+			filter: collector.ItemFilter{IncludeRegex: regexp.MustCompile("^Abyssal")},
+			item:   whip,
+			want:   true,
+		},
+		"include regex miss": {
+			filter: collector.ItemFilter{IncludeRegex: regexp.MustCompile("rune$")},
+			item:   whip,
+			want:   false,
+		},
+		"exclude id": {filter: collector.ItemFilter{ExcludeIDs: []int{4151}}, item: whip, want: false},
+		"exclude name": {
+			filter: collector.ItemFilter{ExcludeNames: []string{"Abyssal whip"}},
+			item:   whip,
+			want:   false,
+		},
+		"exclude regex": {
+			filter: collector.ItemFilter{ExcludeRegex: regexp.MustCompile("whip")},
+			item:   whip,
+			want:   false,
+		},
+		"exclude other": {
+			filter: collector.ItemFilter{ExcludeRegex: regexp.MustCompile("rune")},
+			item:   whip,
+			want:   true,
+		},
+		"exclude over include id": {
+			filter: collector.ItemFilter{IncludeIDs: []int{4151}, ExcludeIDs: []int{4151}},
+			item:   whip,
+			want:   false,
+		},
+		"exclude regex over include name": {
+			filter: collector.ItemFilter{
+				IncludeNames: []string{"Abyssal whip"},
+				ExcludeRegex: regexp.MustCompile("(?i)abyssal"),
+			},
+			item: whip,
+			want: false,
+		},
+		"members on members": {filter: collector.ItemFilter{Members: "members"}, item: whip, want: true},
+		"members on f2p":     {filter: collector.ItemFilter{Members: "members"}, item: nature, want: false},
+		"f2p on f2p":         {filter: collector.ItemFilter{Members: "f2p"}, item: nature, want: true},
+		"f2p on members":     {filter: collector.ItemFilter{Members: "f2p"}, item: whip, want: false},
+		"all on members":     {filter: collector.ItemFilter{Members: "all"}, item: whip, want: true},
+		"min volume met":     {filter: collector.ItemFilter{MinVolume1h: 100}, item: whip, avg1h: avg1h, want: true},
+		"min volume not met": {filter: collector.ItemFilter{MinVolume1h: 101}, item: whip, avg1h: avg1h, want: false},
+		"min volume no 1h":   {filter: collector.ItemFilter{MinVolume1h: 1}, item: whip, want: false},
+		"min volume no sides": {
+			filter: collector.ItemFilter{MinVolume1h: 1},
+			item:   whip,
+			avg1h:  &client.ItemAvg{},
+			want:   false,
+		},
+		"min price met":       {filter: collector.ItemFilter{MinPrice: 1500}, item: whip, latest: latest, want: true},
+		"min price not met":   {filter: collector.ItemFilter{MinPrice: 1501}, item: whip, latest: latest, want: false},
+		"min price no latest": {filter: collector.ItemFilter{MinPrice: 1}, item: whip, want: false},
+		"min price no high": {
+			filter: collector.ItemFilter{MinPrice: 1},
+			item:   whip,
+			latest: &client.ItemLatest{Low: intPtr(1500)},
+			want:   false,
+		},
+		"all criteria": {
+			filter: collector.ItemFilter{
+				IncludeRegex: regexp.MustCompile("(?i)whip"),
+				Members:      "members",
+				MinVolume1h:  100,
+				MinPrice:     1000,
+			},
+			item:   whip,
+			avg1h:  avg1h,
+			latest: latest,
+			want:   true,
+		},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := collector.MatchItem(&tc.filter, tc.item, tc.avg1h, tc.latest); got != tc.want {
+				t.Errorf("expected %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// selection restricts a collection to a subset of items and endpoints, on top
+// of the exporter's filter. The zero value selects everything.
+type selection struct {
+	filter    *itemFilter
+	endpoints map[string]bool
+}
+
+// parseSelection parses a selection from the query parameters of a scrape:
+//
+//   - item: an item ID or exact item name, may be repeated.
+//   - item_regex: a regex matched against item names, may be repeated.
+//   - collect[]: an endpoint whose metrics to collect, one of "mapping",
+//     "5m", "1h" or "latest", may be repeated.
+//
+// Items matching any item or item_regex parameter are selected. Metrics are
+// grouped by the endpoint their prices come from, so the latest group includes
+// the limit profit, which is also capped by the 1h volume.
+func parseSelection(query url.Values) (*selection, error) {
+	sel := &selection{}
+
+	f := &ItemFilter{}
+	for _, item := range query["item"] {
+		if id, err := strconv.Atoi(item); err == nil {
+			f.IncludeIDs = append(f.IncludeIDs, id)
+		} else {
+			f.IncludeNames = append(f.IncludeNames, item)
+		}
+	}
+	if exprs := query["item_regex"]; len(exprs) > 0 {
+		re, err := regexp.Compile("(?:" + strings.Join(exprs, ")|(?:") + ")")
+		if err != nil {
+			return nil, fmt.Errorf("invalid item_regex: %w", err)
+		}
+		f.IncludeRegex = re
+	}
+	if len(f.IncludeIDs) > 0 || len(f.IncludeNames) > 0 || f.IncludeRegex != nil {
+		sel.filter = newItemFilter(f)
+	}
+
+	if collect := query["collect[]"]; len(collect) > 0 {
+		sel.endpoints = map[string]bool{}
+		for _, endpoint := range collect {
+			if !slices.Contains(endpoints, endpoint) {
+				return nil, fmt.Errorf("invalid collect[] %q, must be one of %v", endpoint, endpoints)
+			}
+			sel.endpoints[endpoint] = true
+		}
+	}
+
+	return sel, nil
+}
+
+// matches reports whether the item is selected.
+func (s *selection) matches(item *itemData) bool {
+	return s.filter == nil || s.filter.match(item)
+}
+
+// collects reports whether metrics derived from the endpoint are selected.
+func (s *selection) collects(endpoint string) bool {
+	return s.endpoints == nil || s.endpoints[endpoint]
+}
+
+// selectionCollector collects the item metrics of the selected items and
+// endpoints.
+type selectionCollector struct {
+	e   *Exporter
+	sel *selection
+}
+
+// Describe implements [prometheus.Collector].
+func (c *selectionCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.e.itemDescs() {
+		ch <- desc
+	}
+}
+
+// Collect implements [prometheus.Collector].
+func (c *selectionCollector) Collect(ch chan<- prometheus.Metric) {
+	c.e.collect(ch, c.sel)
+}
+
+// NewHandler returns an http.Handler that serves the item metrics of the
+// exporter alongside those of the given gatherer, which must not include the
+// exporter. To serve the exporter's own metrics as well, the gatherer should
+// include its Telemetry. The items and endpoints to collect can be selected
+// per request using query parameters, see parseSelection.
+func NewHandler(e *Exporter, gatherer prometheus.Gatherer, opts promhttp.HandlerOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sel, err := parseSelection(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		reg := prometheus.NewRegistry()
+		if err := reg.Register(&selectionCollector{e: e, sel: sel}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		// Count the scrape before the gatherer collects the telemetry, which
+		// includes the scrape count.
+		e.totalScrapes.Inc()
+
+		promhttp.HandlerFor(prometheus.Gatherers{gatherer, reg}, opts).ServeHTTP(w, r)
+	})
+}
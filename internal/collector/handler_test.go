@@ -0,0 +1,173 @@
+package collector_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/collector"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var infoIDRegex = regexp.MustCompile(`(?m)^osrs_ge_item_info\{.*\bid="(\d+)"`)
+
+// scrape requests the handler with the given query parameters, and returns
+// the response status and body.
+func scrape(t *testing.T, h http.Handler, query url.Values) (int, string) {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	resp, err := srv.Client().Get(srv.URL + "/metrics?" + query.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, string(body)
+}
+
+// scrapedIDs returns the sorted IDs of the items in a scrape.
+func scrapedIDs(body string) []string {
+	ids := []string{}
+	for _, m := range infoIDRegex.FindAllStringSubmatch(body, -1) {
+		ids = append(ids, m[1])
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	e := newTestExporter(t, 3, &collector.Config{})
+	h := collector.NewHandler(e, prometheus.Gatherers{}, promhttp.HandlerOpts{})
+
+	tcs := map[string]struct {
+		query url.Values
+		ids   []string
+	}{
+		"all items":        {query: url.Values{}, ids: []string{"1", "2", "3", "561"}},
+		"item id":          {query: url.Values{"item": {"2"}}, ids: []string{"2"}},
+		"item name":        {query: url.Values{"item": {"Item 3"}}, ids: []string{"3"}},
+		"item id or name":  {query: url.Values{"item": {"1", "Item 3"}}, ids: []string{"1", "3"}},
+		"item name prefix": {query: url.Values{"item": {"Item"}}, ids: []string{}},
+		"item regex":       {query: url.Values{"item_regex": {"^Item [12]$"}}, ids: []string{"1", "2"}},
+		"repeated item regex": {
+			query: url.Values{"item_regex": {"^Item 1$", "^Item 561$"}},
+			ids:   []string{"1", "561"},
+		},
+		"item and item regex": {
+			query: url.Values{"item": {"3"}, "item_regex": {"^Item 1$"}},
+			ids:   []string{"1", "3"},
+		},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			status, body := scrape(t, h, tc.query)
+			if status != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", status, body)
+			}
+			if ids := scrapedIDs(body); !slices.Equal(ids, tc.ids) {
+				t.Errorf("expected items %v, got %v", tc.ids, ids)
+			}
+		})
+	}
+}
+
+func TestHandlerCollect(t *testing.T) {
+	t.Parallel()
+
+	e := newTestExporter(t, 3, &collector.Config{})
+	h := collector.NewHandler(e, prometheus.Gatherers{}, promhttp.HandlerOpts{})
+
+	status, body := scrape(t, h, url.Values{"item": {"2"}, "collect[]": {"latest", "1h"}})
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", status, body)
+	}
+	for _, want := range []string{
+		`osrs_ge_item_high_latest{id="2"} 240`,
+		`osrs_ge_item_high_1h{id="2"} 240`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("expected %q in scrape", want)
+		}
+	}
+	for _, unwanted := range []string{"osrs_ge_item_info", "osrs_ge_item_value", "osrs_ge_item_high_5m", `id="1"`} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("unexpected %q in scrape", unwanted)
+		}
+	}
+}
+
+func TestHandlerInvalidQuery(t *testing.T) {
+	t.Parallel()
+
+	e := newTestExporter(t, 3, &collector.Config{})
+	h := collector.NewHandler(e, prometheus.Gatherers{}, promhttp.HandlerOpts{})
+
+	for name, query := range map[string]url.Values{
+		"invalid item regex":      {"item_regex": {"^Item 1$", "("}},
+		"unknown collect group":   {"collect[]": {"latest", "prices"}},
+		"empty collect group":     {"collect[]": {""}},
+		"collect group with case": {"collect[]": {"Latest"}},
+	} {
+		query := query
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if status, body := scrape(t, h, query); status != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d: %s", http.StatusBadRequest, status, body)
+			}
+		})
+	}
+}
+
+func TestHandlerTelemetry(t *testing.T) {
+	t.Parallel()
+
+	e := newTestExporter(t, 3, &collector.Config{})
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(e.Telemetry())
+	h := collector.NewHandler(e, reg, promhttp.HandlerOpts{})
+
+	for i := 1; i <= 3; i++ {
+		status, body := scrape(t, h, url.Values{"item": {"2"}})
+		if status != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", status, body)
+		}
+		// Each scrape includes itself in the scrape count.
+		if want := "osrs_ge_exporter_scrapes_total " + strconv.Itoa(i) + "\n"; !strings.Contains(body, want) {
+			t.Errorf("scrape %d: expected %q in scrape", i, want)
+		}
+		if !strings.Contains(body, `osrs_ge_item_value{id="2"} 20`+"\n") {
+			t.Errorf("scrape %d: expected item metrics", i)
+		}
+	}
+
+	// Invalid scrapes are not counted.
+	if status, _ := scrape(t, h, url.Values{"collect[]": {"prices"}}); status != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, status)
+	}
+	if _, body := scrape(t, h, url.Values{"item": {"2"}}); !strings.Contains(body, "osrs_ge_exporter_scrapes_total 4\n") {
+		t.Error("expected invalid scrape not to be counted")
+	}
+}
@@ -0,0 +1,235 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricCache holds the item metrics built from a snapshot. Building the
+// metrics is by far the most expensive part of a collect, so they are only
+// built once for each snapshot and reused by all collects until the next
+// refresh, or until some of the price data becomes stale.
+type metricCache struct {
+	key   metricCacheKey
+	items []*itemMetrics
+}
+
+// metricCacheKey identifies the data that item metrics were built from.
+type metricCacheKey struct {
+	mapping []client.ItemMapping
+	avg5m   *client.DataAvg
+	avg1h   *client.DataAvg
+	latest  *client.DataLatest
+}
+
+func (k metricCacheKey) equal(o metricCacheKey) bool {
+	return k.avg5m == o.avg5m && k.avg1h == o.avg1h && k.latest == o.latest &&
+		len(k.mapping) == len(o.mapping) && (len(k.mapping) == 0 || &k.mapping[0] == &o.mapping[0])
+}
+
+// itemMetrics are the metrics of a single item, by the endpoint they are
+// derived from.
+type itemMetrics struct {
+	item    *itemData
+	labels  []string
+	metrics map[string][]prometheus.Metric
+}
+
+// itemMetrics returns the metrics of all items in the given snapshot, which
+// must already exclude stale data.
+func (e *Exporter) itemMetrics(snap *snapshot) []*itemMetrics {
+	key := metricCacheKey{mapping: snap.mapping, avg5m: snap.avg5m, avg1h: snap.avg1h, latest: snap.latest}
+	if cache := e.metrics.Load(); cache != nil && cache.key.equal(key) {
+		return cache.items
+	}
+
+	// Concurrent collects may both build the metrics, which is harmless.
+	items := e.buildItemMetrics(snap)
+	e.metrics.Store(&metricCache{key: key, items: items})
+
+	return items
+}
+
+// collectItems collects the metrics of the selected items and endpoints.
+func (e *Exporter) collectItems(ch chan<- prometheus.Metric, items []*itemMetrics, sel *selection, now time.Time) {
+	for _, m := range items {
+		if !sel.matches(m.item) {
+			continue
+		}
+
+		for _, endpoint := range endpoints {
+			if !sel.collects(endpoint) {
+				continue
+			}
+			for _, metric := range m.metrics[endpoint] {
+				ch <- metric
+			}
+		}
+
+		// The age of the latest prices changes with every collect.
+		if latestItem := m.item.latest; latestItem != nil && sel.collects(endpointLatest) {
+			if latestItem.HighTime != nil {
+				ch <- gauge(e.ItemHighLatestAge, age(now, *latestItem.HighTime), m.labels)
+			}
+			if latestItem.LowTime != nil {
+				ch <- gauge(e.ItemLowLatestAge, age(now, *latestItem.LowTime), m.labels)
+			}
+		}
+	}
+}
+
+// buildItemMetrics builds the metrics of all items in the given snapshot that
+// match the exporter's filter.
+func (e *Exporter) buildItemMetrics(snap *snapshot) []*itemMetrics {
+	runePrice, runeOK := snap.join(client.ItemMapping{ID: e.alchRuneID()}).buyPrice()
+
+	items := make([]*itemMetrics, 0, len(snap.mapping))
+	for _, mapping := range snap.mapping {
+		item := snap.join(mapping)
+		if !e.filter.match(item) {
+			continue
+		}
+
+		m := &itemMetrics{
+			item:    item,
+			labels:  item.labelValues(e.labels),
+			metrics: make(map[string][]prometheus.Metric, len(endpoints)),
+		}
+		items = append(items, m)
+
+		m.add(endpointMapping, gauge(e.ItemInfo, 1, item.labelValues(infoLabels)))
+		m.addGauge(endpointMapping, e.ItemValue, float64(item.Value))
+		if item.Highalch != nil {
+			m.addGauge(endpointMapping, e.ItemHighAlch, float64(*item.Highalch))
+		}
+		if item.Lowalch != nil {
+			m.addGauge(endpointMapping, e.ItemLowAlch, float64(*item.Lowalch))
+		}
+		if item.Limit != nil {
+			m.addGauge(endpointMapping, e.ItemLimit, float64(*item.Limit))
+		}
+
+		if avgItem := item.avg5m; avgItem != nil {
+			if avgItem.AvgHighPrice != nil {
+				m.addGauge(endpoint5m, e.ItemHigh5m, float64(*avgItem.AvgHighPrice))
+			}
+			if avgItem.AvgLowPrice != nil {
+				m.addGauge(endpoint5m, e.ItemLow5m, float64(*avgItem.AvgLowPrice))
+			}
+			if avgItem.HighPriceVolume != nil {
+				m.addGauge(endpoint5m, e.ItemHighVolume5m, float64(*avgItem.HighPriceVolume))
+			}
+			if avgItem.LowPriceVolume != nil {
+				m.addGauge(endpoint5m, e.ItemLowVolume5m, float64(*avgItem.LowPriceVolume))
+			}
+		}
+		if p, ok := item.prices5m(); ok {
+			m.addMargins(endpoint5m, e.ItemMargin5m, e.ItemSpread5m, e.ItemROI5m, p)
+			m.addPostTaxMargins(endpoint5m, e.ItemPostTaxMargin5m, e.ItemPostTaxROI5m, p, e.tax)
+		}
+
+		if avgItem := item.avg1h; avgItem != nil {
+			if avgItem.AvgHighPrice != nil {
+				m.addGauge(endpoint1h, e.ItemHigh1h, float64(*avgItem.AvgHighPrice))
+			}
+			if avgItem.AvgLowPrice != nil {
+				m.addGauge(endpoint1h, e.ItemLow1h, float64(*avgItem.AvgLowPrice))
+			}
+			if avgItem.HighPriceVolume != nil {
+				m.addGauge(endpoint1h, e.ItemHighVolume1h, float64(*avgItem.HighPriceVolume))
+			}
+			if avgItem.LowPriceVolume != nil {
+				m.addGauge(endpoint1h, e.ItemLowVolume1h, float64(*avgItem.LowPriceVolume))
+			}
+		}
+		if p, ok := item.prices1h(); ok {
+			m.addMargins(endpoint1h, e.ItemMargin1h, e.ItemSpread1h, e.ItemROI1h, p)
+			m.addPostTaxMargins(endpoint1h, e.ItemPostTaxMargin1h, e.ItemPostTaxROI1h, p, e.tax)
+		}
+
+		if latestItem := item.latest; latestItem != nil {
+			if latestItem.High != nil {
+				m.add(endpointLatest, e.latestGauge(e.ItemHighLatest, *latestItem.High, latestItem.HighTime, m.labels))
+			}
+			if latestItem.Low != nil {
+				m.add(endpointLatest, e.latestGauge(e.ItemLowLatest, *latestItem.Low, latestItem.LowTime, m.labels))
+			}
+			if latestItem.HighTime != nil {
+				m.addGauge(endpointLatest, e.ItemHighLatestTime, float64(*latestItem.HighTime))
+			}
+			if latestItem.LowTime != nil {
+				m.addGauge(endpointLatest, e.ItemLowLatestTime, float64(*latestItem.LowTime))
+			}
+		}
+		if p, ok := item.pricesLatest(); ok {
+			m.addMargins(endpointLatest, e.ItemMarginLatest, e.ItemSpreadLatest, e.ItemROILatest, p)
+			m.addPostTaxMargins(endpointLatest, e.ItemPostTaxMarginLatest, e.ItemPostTaxROILatest, p, e.tax)
+			// Limit profit is priced from the latest data, so it is collected
+			// as part of the latest group even though it also needs 1h data.
+			// It is missing while the 1h data is missing or stale.
+			if quantity, ok := item.limitQuantity(); ok {
+				m.addGauge(endpointLatest, e.ItemLimitProfit, float64(p.postTaxMargin(item.ID, e.tax)*quantity))
+			}
+		}
+		if buyPrice, ok := item.buyPrice(); ok && runeOK && item.Highalch != nil {
+			profit := *item.Highalch - buyPrice - runePrice
+			m.addGauge(endpointLatest, e.ItemHighAlchProfit, float64(profit))
+			m.addGauge(endpointLatest, e.ItemHighAlchProfitPerHour, float64(profit)*e.alchCastsPerHour())
+		}
+	}
+
+	return items
+}
+
+func (m *itemMetrics) add(endpoint string, metric prometheus.Metric) {
+	m.metrics[endpoint] = append(m.metrics[endpoint], metric)
+}
+
+func (m *itemMetrics) addGauge(endpoint string, desc *prometheus.Desc, value float64) {
+	m.add(endpoint, gauge(desc, value, m.labels))
+}
+
+// addMargins adds the margin, spread and ROI metrics for the given prices.
+func (m *itemMetrics) addMargins(endpoint string, margin, spread, roi *prometheus.Desc, p prices) {
+	m.addGauge(endpoint, margin, float64(p.margin()))
+	if v, ok := p.spread(); ok {
+		m.addGauge(endpoint, spread, v)
+	}
+	if v, ok := p.roi(); ok {
+		m.addGauge(endpoint, roi, v)
+	}
+}
+
+// addPostTaxMargins adds the post-tax margin and ROI metrics for the given
+// prices.
+func (m *itemMetrics) addPostTaxMargins(endpoint string, margin, roi *prometheus.Desc, p prices, tax *taxModel) {
+	postTaxMargin := p.postTaxMargin(m.item.ID, tax)
+	m.addGauge(endpoint, margin, float64(postTaxMargin))
+	if v, ok := p.ratio(postTaxMargin); ok {
+		m.addGauge(endpoint, roi, v)
+	}
+}
+
+// latestGauge returns a latest price, with the time it was traded as the
+// timestamp if enabled.
+func (e *Exporter) latestGauge(desc *prometheus.Desc, value int, timestamp *int, labels []string) prometheus.Metric {
+	m := gauge(desc, float64(value), labels)
+	if !e.cfg.LatestTimestamps || timestamp == nil {
+		return m
+	}
+
+	return prometheus.NewMetricWithTimestamp(time.Unix(int64(*timestamp), 0), m)
+}
+
+// gauge returns a constant gauge metric.
+func gauge(desc *prometheus.Desc, value float64, labels []string) prometheus.Metric {
+	return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+}
+
+// age returns the seconds elapsed since the given Unix timestamp.
+func age(now time.Time, timestamp int) float64 {
+	return now.Sub(time.Unix(int64(timestamp), 0)).Seconds()
+}
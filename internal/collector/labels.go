@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+// Item label names.
+const (
+	LabelID      = "id"
+	LabelName    = "name"
+	LabelSlug    = "slug"
+	LabelMembers = "members"
+	LabelIcon    = "icon"
+	LabelWikiURL = "wiki_url"
+	LabelExamine = "examine"
+)
+
+const wikiLookupURL = "https://oldschool.runescape.wiki/w/Special:Lookup?type=item&id="
+
+// ItemLabels lists all labels that can be attached to item metrics.
+var ItemLabels = []string{LabelID, LabelName, LabelSlug, LabelMembers, LabelIcon, LabelWikiURL}
+
+// DefaultItemLabels are the labels attached to item metrics if none are
+// configured. Other metadata can be joined from the info metric on id, e.g.
+// `osrs_ge_item_high_latest * on(id) group_left(name) osrs_ge_item_info`.
+var DefaultItemLabels = []string{LabelID}
+
+// infoLabels are the labels of the item info metric.
+var infoLabels = append(slices.Clone(ItemLabels), LabelExamine)
+
+var itemLabelValues = map[string]func(item *client.ItemMapping, id string) string{
+	LabelID:      func(_ *client.ItemMapping, id string) string { return id },
+	LabelName:    func(item *client.ItemMapping, _ string) string { return item.Name },
+	LabelSlug:    func(item *client.ItemMapping, _ string) string { return slugify(item.Name) },
+	LabelMembers: func(item *client.ItemMapping, _ string) string { return boolToString(item.Members) },
+	LabelIcon:    func(item *client.ItemMapping, _ string) string { return item.Icon },
+	LabelWikiURL: func(_ *client.ItemMapping, id string) string { return wikiLookupURL + id },
+	LabelExamine: func(item *client.ItemMapping, _ string) string { return item.Examine },
+}
+
+// itemLabelNames returns the configured item labels, dropping duplicate labels
+// and those not in ItemLabels. The id label is always included, since it is needed to
+// join item metrics with the info metric.
+func itemLabelNames(configured []string) []string {
+	if len(configured) == 0 {
+		configured = DefaultItemLabels
+	}
+
+	names := []string{}
+	for _, name := range configured {
+		if slices.Contains(ItemLabels, name) && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if !slices.Contains(names, LabelID) {
+		names = append([]string{LabelID}, names...)
+	}
+
+	return names
+}
+
+// labelValues returns the values of the given labels for the item.
+func (item *itemData) labelValues(names []string) []string {
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = itemLabelValues[name](&item.ItemMapping, item.id)
+	}
+
+	return values
+}
+
+// slugify returns a lowercase form of name that only contains letters, digits
+// and underscores, e.g. "Dragon dagger(p++)" becomes "dragon_dagger_p".
+func slugify(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r == '\'':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			underscore = false
+			b.WriteRune(r)
+		default:
+			underscore = true
+		}
+	}
+
+	return b.String()
+}
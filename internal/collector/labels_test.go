@@ -0,0 +1,77 @@
+package collector_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/collector"
+)
+
+func TestSlugify(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]string{
+		"Abyssal whip":        "abyssal_whip",
+		"Dragon dagger(p++)":  "dragon_dagger_p",
+		"Saradomin's tear":    "saradomins_tear",
+		"Prayer potion(4)":    "prayer_potion_4",
+		"  Leading - spaces ": "leading_spaces",
+		"3rd age amulet":      "3rd_age_amulet",
+		"Jalapeño":            "jalapeño",
+		"Ring of wealth (i5)": "ring_of_wealth_i5",
+		"":                    "",
+		"+++":                 "",
+	}
+	for name, want := range tcs {
+		if got := collector.Slugify(name); got != want {
+			t.Errorf("slugify(%q): expected %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestItemLabelNames(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		configured []string
+		want       []string
+	}{
+		"default":      {configured: nil, want: []string{"id"}},
+		"id added":     {configured: []string{"name"}, want: []string{"id", "name"}},
+		"order kept":   {configured: []string{"slug", "id", "name"}, want: []string{"slug", "id", "name"}},
+		"duplicates":   {configured: []string{"name", "name", "id"}, want: []string{"name", "id"}},
+		"unknown":      {configured: []string{"name", "price"}, want: []string{"id", "name"}},
+		"examine":      {configured: []string{"examine", "members"}, want: []string{"id", "members"}},
+		"only invalid": {configured: []string{"examine"}, want: []string{"id"}},
+		"all":          {configured: collector.ItemLabels, want: collector.ItemLabels},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := collector.ItemLabelNames(tc.configured); !slices.Equal(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestExamineLabelRejected(t *testing.T) {
+	t.Parallel()
+
+	e := newTestExporter(t, 3, &collector.Config{Labels: []string{"name", "examine"}})
+	mfs := gather(t, e)
+	for _, l := range findMetric(mfs["osrs_ge_item_high_latest"], "2").GetLabel() {
+		if l.GetName() == "examine" {
+			t.Error("unexpected examine label on item metric")
+		}
+	}
+	found := false
+	for _, l := range findMetric(mfs["osrs_ge_item_info"], "2").GetLabel() {
+		found = found || l.GetName() == "examine"
+	}
+	if !found {
+		t.Error("expected examine label on info metric")
+	}
+}
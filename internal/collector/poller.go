@@ -0,0 +1,194 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/log"
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+const (
+	defaultRefreshInterval        = time.Minute
+	defaultMappingRefreshInterval = time.Hour
+)
+
+// Run refreshes the market data immediately and then on every refresh
+// interval, until ctx is done.
+func (e *Exporter) Run(ctx context.Context) {
+	interval := e.cfg.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Refresh(ctx); err != nil {
+			reason := failureReason(err)
+			log.Error(e.logger).Log("msg", "Refresh failed", "reason", reason, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh fetches the prices and publishes them as a new snapshot. The item
+// mapping is reused from the previous snapshot unless it is due for a refresh.
+// Endpoints fail independently, and failed endpoints keep serving their data
+// from the previous snapshot. If all endpoints fail, no new snapshot is
+// published, so the age of the served data keeps growing. The returned error
+// joins the errors of all failed endpoints.
+func (e *Exporter) Refresh(ctx context.Context) error {
+	if e.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.cfg.Timeout)
+		defer cancel()
+	}
+
+	prev := e.snapshot.Load()
+	snap, results := e.fetch(ctx, prev)
+	if snap != prev {
+		e.snapshot.Store(snap)
+		for endpoint, t := range snap.fetched {
+			e.lastSuccess.WithLabelValues(endpoint).Set(float64(t.Unix()))
+		}
+	}
+
+	var errs []error
+	for _, endpoint := range endpoints {
+		err, ok := results[endpoint]
+		switch {
+		case !ok:
+			continue
+		case err != nil:
+			e.endpointUp.WithLabelValues(endpoint).Set(0)
+			e.queryFailures.WithLabelValues(endpoint, failureReason(err)).Inc()
+			errs = append(errs, err)
+		default:
+			e.endpointUp.WithLabelValues(endpoint).Set(1)
+		}
+	}
+	if len(errs) > 0 {
+		e.up.Set(0)
+		e.refreshes.WithLabelValues("failure").Inc()
+
+		return errors.Join(errs...)
+	}
+
+	e.up.Set(1)
+	e.refreshes.WithLabelValues("success").Inc()
+
+	return nil
+}
+
+// fetch returns the next snapshot after prev, along with the result of each
+// endpoint that was fetched. If no endpoint was fetched successfully, prev is
+// returned.
+func (e *Exporter) fetch(ctx context.Context, prev *snapshot) (*snapshot, map[string]error) {
+	var (
+		now     = time.Now()
+		mapping []client.ItemMapping
+		avg5m   *client.DataAvg
+		avg1h   *client.DataAvg
+		latest  *client.DataLatest
+	)
+
+	g := newFetchGroup(ctx, e.fetchDuration)
+	if prev == nil || now.Sub(prev.fetched[endpointMapping]) >= e.mappingRefreshInterval() {
+		g.Go(endpointMapping, func(ctx context.Context) error {
+			var err error
+			if mapping, err = e.client.GetMapping(ctx, nil); err != nil {
+				return fmt.Errorf("failed to get mapping: %w", err)
+			}
+
+			return nil
+		})
+	}
+	g.Go(endpoint5m, func(ctx context.Context) error {
+		var err error
+		if avg5m, err = e.client.Get5m(ctx, nil); err != nil {
+			return fmt.Errorf("failed to get 5m avg: %w", err)
+		}
+
+		return nil
+	})
+	g.Go(endpoint1h, func(ctx context.Context) error {
+		var err error
+		if avg1h, err = e.client.Get1h(ctx, nil); err != nil {
+			return fmt.Errorf("failed to get 1h avg: %w", err)
+		}
+
+		return nil
+	})
+	g.Go(endpointLatest, func(ctx context.Context) error {
+		var err error
+		if latest, err = e.client.GetLatest(ctx, nil); err != nil {
+			return fmt.Errorf("failed to get latest: %w", err)
+		}
+
+		return nil
+	})
+	results := g.Wait()
+
+	succeeded := false
+	for _, err := range results {
+		succeeded = succeeded || err == nil
+	}
+	if !succeeded {
+		return prev, results
+	}
+
+	snap := prev.next(now)
+	if err, ok := results[endpointMapping]; ok && err == nil {
+		snap.mapping = mapping
+		snap.fetched[endpointMapping] = now
+	}
+	if err, ok := results[endpoint5m]; ok && err == nil {
+		snap.avg5m = avg5m
+		snap.fetched[endpoint5m] = now
+	}
+	if err, ok := results[endpoint1h]; ok && err == nil {
+		snap.avg1h = avg1h
+		snap.fetched[endpoint1h] = now
+	}
+	if err, ok := results[endpointLatest]; ok && err == nil {
+		snap.latest = latest
+		snap.fetched[endpointLatest] = now
+	}
+
+	return snap, results
+}
+
+func (e *Exporter) mappingRefreshInterval() time.Duration {
+	if e.cfg.MappingRefreshInterval <= 0 {
+		return defaultMappingRefreshInterval
+	}
+
+	return e.cfg.MappingRefreshInterval
+}
+
+// failureReason classifies a refresh error for the query failures metric.
+func failureReason(err error) string {
+	apiErr := &client.APIError{}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &apiErr) && apiErr.RateLimited():
+		return "rate_limited"
+	case errors.As(err, &apiErr):
+		return "upstream"
+	default:
+		return "error"
+	}
+}
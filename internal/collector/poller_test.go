@@ -0,0 +1,275 @@
+package collector_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/collector"
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMappingRefreshInterval(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		interval time.Duration
+		requests int
+	}{
+		"below interval": {interval: time.Hour, requests: 1},
+		"above interval": {interval: time.Nanosecond, requests: 2},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := newFakeAPI(t, 3)
+			e := api.exporter(t, &collector.Config{MappingRefreshInterval: tc.interval})
+			for i := 0; i < 2; i++ {
+				if err := e.Refresh(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if n := api.requests("mapping"); n != tc.requests {
+				t.Errorf("expected %d mapping requests, got %d", tc.requests, n)
+			}
+			if n := api.requests("latest"); n != 2 {
+				t.Errorf("expected 2 latest requests, got %d", n)
+			}
+		})
+	}
+}
+
+// gather returns the metric families collected from the exporter by name.
+func gather(t *testing.T, e *collector.Exporter) map[string]*dto.MetricFamily {
+	t.Helper()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(e)
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]*dto.MetricFamily, len(mfs))
+	for _, mf := range mfs {
+		byName[mf.GetName()] = mf
+	}
+
+	return byName
+}
+
+func TestRefreshKeepsLastKnownGood(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeAPI(t, 3)
+	e := api.exporter(t, &collector.Config{})
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	for _, endpoint := range []string{"mapping", "5m", "1h", "latest"} {
+		api.fail(endpoint, true)
+	}
+	if err := e.Refresh(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	mfs := gather(t, e)
+	for _, name := range []string{
+		"osrs_ge_item_value", "osrs_ge_item_high_5m", "osrs_ge_item_high_1h", "osrs_ge_item_high_latest",
+	} {
+		if len(mfs[name].GetMetric()) != 4 {
+			t.Errorf("%s: expected 4 series, got %d", name, len(mfs[name].GetMetric()))
+		}
+	}
+	// The snapshot must not be renewed by a refresh without any new data.
+	if age := metricValue(findMetric(mfs["osrs_ge_exporter_snapshot_age_seconds"], "")); age < 0.05 {
+		t.Errorf("expected snapshot age of at least 50ms, got %vs", age)
+	}
+	if up := metricValue(findMetric(mfs["osrs_ge_up"], "")); up != 0 {
+		t.Errorf("expected up 0, got %v", up)
+	}
+}
+
+func TestMaxStaleness(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeAPI(t, 3)
+	e := api.exporter(t, &collector.Config{MaxStaleness: 50 * time.Millisecond})
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	api.fail("5m", true)
+	if err := e.Refresh(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := len(gather(t, e)["osrs_ge_item_high_5m"].GetMetric()); n != 4 {
+		t.Fatalf("expected 5m data within MaxStaleness to be kept, got %d series", n)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if err := e.Refresh(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	mfs := gather(t, e)
+	if _, ok := mfs["osrs_ge_item_high_5m"]; ok {
+		t.Error("expected stale 5m data to be dropped")
+	}
+	if _, ok := mfs["osrs_ge_item_margin_5m"]; ok {
+		t.Error("expected metrics derived from stale 5m data to be dropped")
+	}
+	if n := len(mfs["osrs_ge_item_high_latest"].GetMetric()); n != 4 {
+		t.Errorf("expected fresh latest data to be kept, got %d series", n)
+	}
+	if n := len(mfs["osrs_ge_item_value"].GetMetric()); n != 4 {
+		t.Errorf("expected the mapping to be kept, got %d series", n)
+	}
+}
+
+func TestEndpointFailure(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeAPI(t, 3)
+	e := api.exporter(t, &collector.Config{})
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	api.fail("1h", true)
+	err := e.Refresh(context.Background())
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(e)
+	expected := `
+# HELP osrs_ge_endpoint_up Was the last fetch from each endpoint successful.
+# TYPE osrs_ge_endpoint_up gauge
+osrs_ge_endpoint_up{endpoint="1h"} 0
+osrs_ge_endpoint_up{endpoint="5m"} 1
+osrs_ge_endpoint_up{endpoint="latest"} 1
+osrs_ge_endpoint_up{endpoint="mapping"} 1
+# HELP osrs_ge_exporter_query_failures_total Number of errors, by endpoint and reason.
+# TYPE osrs_ge_exporter_query_failures_total counter
+osrs_ge_exporter_query_failures_total{endpoint="1h",reason="upstream"} 1
+# HELP osrs_ge_up Was the last refresh of all endpoints successful.
+# TYPE osrs_ge_up gauge
+osrs_ge_up 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"osrs_ge_endpoint_up", "osrs_ge_exporter_query_failures_total", "osrs_ge_up"); err != nil {
+		t.Error(err)
+	}
+
+	mfs := gather(t, e)
+	want := map[string]float64{
+		// The previous 1h data keeps being served.
+		"osrs_ge_item_high_1h":       240,
+		"osrs_ge_item_roi_ratio_1h":  0.2,
+		"osrs_ge_item_high_5m":       240,
+		"osrs_ge_item_high_latest":   240,
+		"osrs_ge_item_margin_latest": 40,
+	}
+	for name, v := range want {
+		m := findMetric(mfs[name], "2")
+		if m == nil {
+			t.Errorf("%s: no metric for item 2", name)
+
+			continue
+		}
+		if got := metricValue(m); got != v {
+			t.Errorf("%s: expected %v, got %v", name, v, got)
+		}
+	}
+}
+
+func TestRefreshTimeout(t *testing.T) {
+	t.Parallel()
+
+	api := newFakeAPI(t, 3)
+	api.delay("latest", time.Minute)
+	e := api.exporter(t, &collector.Config{Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	err := e.Refresh(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("refresh took %v despite the timeout", elapsed)
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(e)
+	expected := `
+# HELP osrs_ge_endpoint_up Was the last fetch from each endpoint successful.
+# TYPE osrs_ge_endpoint_up gauge
+osrs_ge_endpoint_up{endpoint="1h"} 1
+osrs_ge_endpoint_up{endpoint="5m"} 1
+osrs_ge_endpoint_up{endpoint="latest"} 0
+osrs_ge_endpoint_up{endpoint="mapping"} 1
+# HELP osrs_ge_exporter_query_failures_total Number of errors, by endpoint and reason.
+# TYPE osrs_ge_exporter_query_failures_total counter
+osrs_ge_exporter_query_failures_total{endpoint="latest",reason="timeout"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"osrs_ge_endpoint_up", "osrs_ge_exporter_query_failures_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRefreshConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const delay = 300 * time.Millisecond
+
+	api := newFakeAPI(t, 3)
+	endpoints := []string{"mapping", "5m", "1h", "latest"}
+	for _, endpoint := range endpoints {
+		api.delay(endpoint, delay)
+	}
+	e := api.exporter(t, &collector.Config{})
+
+	start := time.Now()
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Sequential fetches would take four times the delay.
+	if elapsed := time.Since(start); elapsed >= 2*delay {
+		t.Errorf("expected endpoints to be fetched concurrently, refresh took %v", elapsed)
+	}
+
+	durations := map[string]float64{}
+	for _, m := range gather(t, e)["osrs_ge_exporter_fetch_duration_seconds"].GetMetric() {
+		for _, l := range m.GetLabel() {
+			if l.GetName() == "endpoint" {
+				durations[l.GetValue()] = metricValue(m)
+			}
+		}
+	}
+	for _, endpoint := range endpoints {
+		d, ok := durations[endpoint]
+		if !ok {
+			t.Errorf("%s: no fetch duration", endpoint)
+
+			continue
+		}
+		if d < delay.Seconds() {
+			t.Errorf("%s: expected a fetch duration of at least %v, got %vs", endpoint, delay, d)
+		}
+	}
+}
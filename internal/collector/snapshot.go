@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+// snapshot is the market data served by the exporter. A snapshot must not be
+// modified once it has been published. Each refresh publishes a new snapshot
+// which carries over the data of any endpoint that was not fetched
+// successfully, so the last known good data keeps being served.
+type snapshot struct {
+	mapping []client.ItemMapping
+	avg5m   *client.DataAvg
+	avg1h   *client.DataAvg
+	latest  *client.DataLatest
+
+	// fetched holds the time each endpoint was last fetched successfully.
+	fetched map[string]time.Time
+	// time is when the snapshot was published.
+	time time.Time
+}
+
+// next returns a copy of s to be filled by the refresh at the given time.
+func (s *snapshot) next(now time.Time) *snapshot {
+	n := &snapshot{
+		fetched: map[string]time.Time{},
+		time:    now,
+	}
+	if s == nil {
+		return n
+	}
+
+	n.mapping, n.avg5m, n.avg1h, n.latest = s.mapping, s.avg5m, s.avg1h, s.latest
+	for endpoint, t := range s.fetched {
+		n.fetched[endpoint] = t
+	}
+
+	return n
+}
+
+// emptyAvg and emptyLatest replace missing or stale price data. They are
+// shared, so that they can be told apart from fetched data by identity.
+var (
+	emptyAvg    = &client.DataAvg{}
+	emptyLatest = &client.DataLatest{}
+)
+
+// fresh returns a copy of s without the price data that is older than maxAge,
+// or missing entirely. The mapping is always kept, since all other data is
+// joined against it. A maxAge of zero keeps all data.
+func (s *snapshot) fresh(now time.Time, maxAge time.Duration) *snapshot {
+	f := *s
+	if f.avg5m == nil || s.stale(endpoint5m, now, maxAge) {
+		f.avg5m = emptyAvg
+	}
+	if f.avg1h == nil || s.stale(endpoint1h, now, maxAge) {
+		f.avg1h = emptyAvg
+	}
+	if f.latest == nil || s.stale(endpointLatest, now, maxAge) {
+		f.latest = emptyLatest
+	}
+
+	return &f
+}
+
+func (s *snapshot) stale(endpoint string, now time.Time, maxAge time.Duration) bool {
+	return maxAge > 0 && now.Sub(s.fetched[endpoint]) > maxAge
+}
+
+// itemData is the data of all endpoints joined for a single item. Price data
+// that is missing for the item is nil.
+type itemData struct {
+	client.ItemMapping
+
+	id     string
+	avg5m  *client.ItemAvg
+	avg1h  *client.ItemAvg
+	latest *client.ItemLatest
+
+	// time5m and time1h are the start of the averaged windows, or the time the
+	// snapshot was published if the API did not send it.
+	time5m time.Time
+	time1h time.Time
+	// time is when the snapshot was published.
+	time time.Time
+}
+
+// join returns the data of all endpoints for the given item.
+func (s *snapshot) join(mapping client.ItemMapping) *itemData {
+	item := &itemData{
+		ItemMapping: mapping,
+		id:          strconv.Itoa(mapping.ID),
+		time5m:      avgTime(s.avg5m, s.time),
+		time1h:      avgTime(s.avg1h, s.time),
+		time:        s.time,
+	}
+	if v, ok := s.avg5m.Data[item.id]; ok {
+		item.avg5m = &v
+	}
+	if v, ok := s.avg1h.Data[item.id]; ok {
+		item.avg1h = &v
+	}
+	if v, ok := s.latest.Data[item.id]; ok {
+		item.latest = &v
+	}
+
+	return item
+}
+
+// avgTime returns the start of the window of the average prices, or fallback if
+// the response did not include it. Pricing the window at 1970 would predate all
+// tax rates.
+func avgTime(avg *client.DataAvg, fallback time.Time) time.Time {
+	if avg.Timestamp == 0 {
+		return fallback
+	}
+
+	return avg.Time()
+}
@@ -0,0 +1,143 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const taxRateDateLayout = "2006-01-02"
+
+var errInvalidTaxRate = errors.New("invalid tax rate")
+
+// TaxRate is a Grand Exchange tax rate, in effect from Since until the next
+// rate in the schedule.
+type TaxRate struct {
+	// Since is when the rate came into effect.
+	Since time.Time
+	// Rate is the fraction of the sell price that is charged.
+	Rate float64
+	// Cap is the maximum tax charged per item. Zero disables the cap.
+	Cap int
+}
+
+// TaxModel describes the tax charged on Grand Exchange sales.
+type TaxModel struct {
+	// Rates is the history of tax rates. Sales before the first rate are
+	// not taxed. Defaults to DefaultTaxRates.
+	Rates []TaxRate
+	// ExemptIDs are the items that are never taxed. Defaults to
+	// DefaultTaxExemptIDs.
+	ExemptIDs []int
+}
+
+var (
+	// DefaultTaxRates are the Grand Exchange tax rates since the tax was
+	// introduced.
+	DefaultTaxRates = []TaxRate{
+		{Since: time.Date(2021, time.December, 9, 0, 0, 0, 0, time.UTC), Rate: 0.01, Cap: 5_000_000},
+		{Since: time.Date(2025, time.May, 29, 0, 0, 0, 0, time.UTC), Rate: 0.02, Cap: 5_000_000},
+	}
+
+	// DefaultTaxExemptIDs are the items exempt from the Grand Exchange tax:
+	// old school bonds and a number of low-level tools.
+	DefaultTaxExemptIDs = []int{
+		13190, // Old school bond
+		233,   // Pestle and mortar
+		952,   // Spade
+		1733,  // Needle
+		1735,  // Shears
+		1755,  // Chisel
+		1785,  // Glassblowing pipe
+		2347,  // Hammer
+		5325,  // Gardening trowel
+		5329,  // Secateurs
+		5341,  // Rake
+		5343,  // Seed dibber
+		8794,  // Saw
+	}
+)
+
+// ParseTaxRate parses a tax rate of the form "since:rate[:cap]", where since
+// is a date such as 2025-05-29, e.g. "2025-05-29:0.02:5000000".
+func ParseTaxRate(s string) (TaxRate, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return TaxRate{}, fmt.Errorf("%w %q: expected since:rate[:cap]", errInvalidTaxRate, s)
+	}
+
+	since, err := time.Parse(taxRateDateLayout, parts[0])
+	if err != nil {
+		return TaxRate{}, fmt.Errorf("%w %q: %w", errInvalidTaxRate, s, err)
+	}
+	rate, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || !(rate >= 0 && rate <= 1) { // Also rejects NaN.
+		return TaxRate{}, fmt.Errorf("%w %q: rate must be between 0 and 1", errInvalidTaxRate, s)
+	}
+	taxRate := TaxRate{Since: since, Rate: rate}
+	if len(parts) == 3 {
+		taxRate.Cap, err = strconv.Atoi(parts[2])
+		if err != nil || taxRate.Cap < 0 {
+			return TaxRate{}, fmt.Errorf("%w %q: cap must be a non-negative integer", errInvalidTaxRate, s)
+		}
+	}
+
+	return taxRate, nil
+}
+
+// taxModel calculates the tax charged on sales.
+type taxModel struct {
+	rates  []TaxRate
+	exempt map[int]bool
+}
+
+func newTaxModel(m *TaxModel) *taxModel {
+	rates := m.Rates
+	if rates == nil {
+		rates = DefaultTaxRates
+	}
+	exemptIDs := m.ExemptIDs
+	if exemptIDs == nil {
+		exemptIDs = DefaultTaxExemptIDs
+	}
+
+	rates = slices.Clone(rates)
+	slices.SortFunc(rates, func(a, b TaxRate) int {
+		return a.Since.Compare(b.Since)
+	})
+
+	return &taxModel{
+		rates:  rates,
+		exempt: toSet(exemptIDs),
+	}
+}
+
+// tax returns the tax charged when selling the given item at the given price
+// and time.
+func (m *taxModel) tax(id, price int, t time.Time) int {
+	if m.exempt[id] {
+		return 0
+	}
+
+	var rate *TaxRate
+	for i := range m.rates {
+		if m.rates[i].Since.After(t) {
+			break
+		}
+		rate = &m.rates[i]
+	}
+	if rate == nil {
+		return 0
+	}
+
+	tax := int(math.Floor(float64(price) * rate.Rate))
+	if rate.Cap > 0 && tax > rate.Cap {
+		return rate.Cap
+	}
+
+	return tax
+}
@@ -0,0 +1,127 @@
+package collector_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/collector"
+)
+
+func TestTax(t *testing.T) {
+	t.Parallel()
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	custom := &collector.TaxModel{
+		// Out of order, to check that rates are sorted.
+		Rates: []collector.TaxRate{
+			{Since: date(2024, time.January, 1), Rate: 0.05},
+			{Since: date(2020, time.January, 1), Rate: 0.1, Cap: 50},
+		},
+		ExemptIDs: []int{},
+	}
+
+	tcs := map[string]struct {
+		model *collector.TaxModel
+		id    int
+		price int
+		time  time.Time
+		want  int
+	}{
+		"before tax":         {id: 4151, price: 1_000_000, time: date(2021, time.December, 8), want: 0},
+		"first 1% day":       {id: 4151, price: 1_000_000, time: date(2021, time.December, 9), want: 10_000},
+		"1% period":          {id: 4151, price: 1_000_000, time: date(2023, time.June, 1), want: 10_000},
+		"1% rounded down":    {id: 4151, price: 199, time: date(2023, time.June, 1), want: 1},
+		"1% below one coin":  {id: 4151, price: 99, time: date(2023, time.June, 1), want: 0},
+		"last 1% second":     {id: 4151, price: 1_000_000, time: date(2025, time.May, 29).Add(-time.Second), want: 10_000},
+		"first 2% day":       {id: 4151, price: 1_000_000, time: date(2025, time.May, 29), want: 20_000},
+		"2% period":          {id: 4151, price: 1_000_000, time: date(2026, time.January, 1), want: 20_000},
+		"1% at cap":          {id: 20997, price: 500_000_000, time: date(2023, time.June, 1), want: 5_000_000},
+		"1% above cap":       {id: 20997, price: 1_500_000_000, time: date(2023, time.June, 1), want: 5_000_000},
+		"2% above cap":       {id: 20997, price: 1_500_000_000, time: date(2026, time.January, 1), want: 5_000_000},
+		"2% below cap":       {id: 20997, price: 200_000_000, time: date(2026, time.January, 1), want: 4_000_000},
+		"exempt bond":        {id: 13190, price: 10_000_000, time: date(2026, time.January, 1), want: 0},
+		"exempt tool":        {id: 952, price: 100, time: date(2026, time.January, 1), want: 0},
+		"custom before":      {model: custom, id: 4151, price: 1000, time: date(2019, time.June, 1), want: 0},
+		"custom capped":      {model: custom, id: 4151, price: 1000, time: date(2022, time.June, 1), want: 50},
+		"custom uncapped":    {model: custom, id: 4151, price: 1000, time: date(2024, time.June, 1), want: 50},
+		"custom no exempt":   {model: custom, id: 13190, price: 100, time: date(2024, time.June, 1), want: 5},
+		"custom later rate":  {model: custom, id: 4151, price: 10_000, time: date(2024, time.June, 1), want: 500},
+		"custom earlier cap": {model: custom, id: 4151, price: 10_000, time: date(2023, time.June, 1), want: 50},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			model := tc.model
+			if model == nil {
+				model = &collector.TaxModel{}
+			}
+			if got := collector.Tax(model, tc.id, tc.price, tc.time); got != tc.want {
+				t.Errorf("expected tax %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTaxRate(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		input string
+		want  collector.TaxRate
+		err   bool
+	}{
+		"rate": {
+			input: "2021-12-09:0.01",
+			want:  collector.TaxRate{Since: time.Date(2021, time.December, 9, 0, 0, 0, 0, time.UTC), Rate: 0.01},
+		},
+		"rate and cap": {
+			input: "2025-05-29:0.02:5000000",
+			want: collector.TaxRate{
+				Since: time.Date(2025, time.May, 29, 0, 0, 0, 0, time.UTC), Rate: 0.02, Cap: 5_000_000,
+			},
+		},
+		"zero rate":     {input: "2030-01-01:0", want: collector.TaxRate{Since: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		"empty":         {input: "", err: true},
+		"date only":     {input: "2025-05-29", err: true},
+		"too many":      {input: "2025-05-29:0.02:5000000:1", err: true},
+		"invalid date":  {input: "2025-13-01:0.02", err: true},
+		"date format":   {input: "29/05/2025:0.02", err: true},
+		"empty date":    {input: ":0.02", err: true},
+		"invalid rate":  {input: "2025-05-29:two", err: true},
+		"percent rate":  {input: "2025-05-29:2%", err: true},
+		"negative rate": {input: "2025-05-29:-0.01", err: true},
+		"rate above 1":  {input: "2025-05-29:2", err: true},
+		"nan rate":      {input: "2025-05-29:NaN", err: true},
+		"inf rate":      {input: "2025-05-29:Inf", err: true},
+		"empty rate":    {input: "2025-05-29:", err: true},
+		"invalid cap":   {input: "2025-05-29:0.02:5m", err: true},
+		"float cap":     {input: "2025-05-29:0.02:5e6", err: true},
+		"negative cap":  {input: "2025-05-29:0.02:-1", err: true},
+		"empty cap":     {input: "2025-05-29:0.02:", err: true},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := collector.ParseTaxRate(tc.input)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Since.Equal(tc.want.Since) || got.Rate != tc.want.Rate || got.Cap != tc.want.Cap {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package version
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"runtime"
 	"runtime/debug"
 
 	"github.com/MacroPower/osrs_ge_exporter/internal/log"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
@@ -20,6 +24,81 @@ var (
 	GoArch    = runtime.GOARCH
 )
 
+// Info is the version and build context of the binary.
+type Info struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildUser string `json:"buildUser"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	GoOS      string `json:"goOS"`
+	GoArch    string `json:"goArch"`
+}
+
+// Get returns the version and build context of the binary.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Revision:  Revision,
+		Branch:    Branch,
+		BuildUser: BuildUser,
+		BuildDate: BuildDate,
+		GoVersion: GoVersion,
+		GoOS:      GoOS,
+		GoArch:    GoArch,
+	}
+}
+
+// JSON returns the version and build context of the binary as indented JSON.
+func JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Get(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal version: %w", err)
+	}
+
+	return data, nil
+}
+
+// Handler returns an http.Handler that serves the version and build context
+// of the binary as JSON.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		data, err := JSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
+
+// NewCollector returns a collector that exports a <program>_build_info
+// metric, with the version and build context as labels and a value of 1.
+func NewCollector(program string) prometheus.Collector { //nolint:ireturn // Hides the metric type.
+	info := Get()
+
+	return prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: program + "_build_info",
+			Help: fmt.Sprintf("A metric with a constant '1' value labeled by the version and build context of %s.", program),
+			ConstLabels: prometheus.Labels{
+				"version":   info.Version,
+				"revision":  info.Revision,
+				"branch":    info.Branch,
+				"builduser": info.BuildUser,
+				"builddate": info.BuildDate,
+				"goversion": info.GoVersion,
+				"goos":      info.GoOS,
+				"goarch":    info.GoArch,
+			},
+		},
+		func() float64 { return 1 },
+	)
+}
+
 // LogInfo logs version, branch and revision.
 func LogInfo(logger log.Logger) error {
 	if err := log.Info(logger).Log(
@@ -0,0 +1,81 @@
+package version_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/MacroPower/osrs_ge_exporter/internal/version"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMain(m *testing.M) {
+	// Set the variables that are usually set via ldflags before any test
+	// runs, since tests run in parallel.
+	version.Version = "1.2.3"
+	version.Branch = "main"
+	version.BuildUser = "user@host"
+	version.BuildDate = "20240101-00:00:00"
+	version.Revision = "abc123"
+
+	os.Exit(m.Run())
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	version.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	got := map[string]string{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"version":   "1.2.3",
+		"revision":  "abc123",
+		"branch":    "main",
+		"buildUser": "user@host",
+		"buildDate": "20240101-00:00:00",
+		"goVersion": runtime.Version(),
+		"goOS":      runtime.GOOS,
+		"goArch":    runtime.GOARCH,
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d fields, got %v", len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got[k])
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	expected := `
+# HELP osrs_ge_exporter_build_info A metric with a constant '1' value labeled by the version and build context ` +
+		`of osrs_ge_exporter.
+# TYPE osrs_ge_exporter_build_info gauge
+osrs_ge_exporter_build_info{branch="main",builddate="20240101-00:00:00",builduser="user@host",goarch="` +
+		runtime.GOARCH + `",goos="` + runtime.GOOS + `",goversion="` + runtime.Version() +
+		`",revision="abc123",version="1.2.3"} 1
+`
+	c := version.NewCollector("osrs_ge_exporter")
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,55 +1,193 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"time"
 )
 
+// maxBackoff is the longest delay that backoff doubles, so that doubling it
+// cannot overflow.
+const maxBackoff = time.Duration(math.MaxInt64 / 2)
+
+// Client sends requests to an API. It is a [prometheus.Collector] exposing
+// metrics about the requests it sent.
 type Client struct {
 	baseURL string
 	client  *http.Client
+	retry   RetryConfig
+	metrics *metrics
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// RetryConfig controls how failed requests are retried. Requests are retried
+// on transport errors, 429 and 5xx responses, but never once the request
+// context is done.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of retries per request. Zero disables
+	// retries.
+	MaxRetries int
+	// MinBackoff is the base delay before the first retry. It doubles with
+	// every subsequent retry.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries, including any Retry-After
+	// sent by the API, so that a misbehaving API cannot block a request for
+	// longer. Zero disables the cap.
+	MaxBackoff time.Duration
+}
+
+// WithHTTPClient replaces the http.Client used to send requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithRetry enables retries using the given configuration.
+func WithRetry(cfg RetryConfig) Option {
+	return func(c *Client) {
+		c.retry = cfg
+	}
 }
 
-func NewClient(baseURL string, client *http.Client) *Client {
-	return &Client{
+func NewClient(baseURL string, client *http.Client, opts ...Option) *Client {
+	c := &Client{
 		baseURL: baseURL,
 		client:  client,
+		metrics: newMetrics(),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
 }
 
+// Get sends a GET request for the given query. A response with a non-2xx
+// status code is returned along with an *APIError.
 func (r *Client) Get(ctx context.Context, query string, params url.Values) ([]byte, int, error) {
-	return r.doRequest(ctx, "GET", query, params, nil)
+	return r.doRequest(ctx, http.MethodGet, query, params, nil)
 }
 
 func (r *Client) doRequest(
-	ctx context.Context, method, query string, params url.Values, buf io.Reader,
+	ctx context.Context, method, query string, params url.Values, body []byte,
+) ([]byte, int, error) {
+	for attempt := 0; ; attempt++ {
+		data, code, err := r.doAttempt(ctx, method, query, params, body)
+		if err == nil || attempt >= r.retry.MaxRetries || !retryable(ctx, err) {
+			return data, code, err
+		}
+
+		wait := r.backoff(attempt, err)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return data, code, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return data, code, fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *Client) doAttempt(
+	ctx context.Context, method, query string, params url.Values, body []byte,
 ) ([]byte, int, error) {
 	u, _ := url.Parse(r.baseURL)
 	u.Path = path.Join(u.Path, query)
 	if params != nil {
 		u.RawQuery = params.Encode()
 	}
-	req, err := http.NewRequest(method, u.String(), buf)
+	var buf io.Reader
+	if body != nil {
+		buf = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
-	req = req.WithContext(ctx)
 	req.Header.Set("Accept", "*/*")
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "https://github.com/MacroPower/osrs_ge_exporter")
+	start := time.Now()
 	resp, err := r.client.Do(req)
 	if err != nil {
+		r.metrics.requestDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+		r.metrics.responses.WithLabelValues(query, codeError).Inc()
+
 		return nil, 0, fmt.Errorf("failed request: %w", err)
 	}
 	data, err := io.ReadAll(resp.Body)
 	resp.Body.Close()
+	r.metrics.requestDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+	r.metrics.responses.WithLabelValues(query, strconv.Itoa(resp.StatusCode)).Inc()
+	r.metrics.responseBytes.WithLabelValues(query).Add(float64(len(data)))
 	if err != nil {
 		return nil, resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
 	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return data, resp.StatusCode, newAPIError(query, resp, data)
+	}
 
 	return data, resp.StatusCode, nil
 }
+
+// backoff returns the delay before the given retry attempt, using exponential
+// backoff with jitter. A Retry-After sent by the API is honored if it is
+// longer, up to MaxBackoff.
+func (r *Client) backoff(attempt int, err error) time.Duration {
+	// Stop doubling once the delay reaches the cap, or before it overflows.
+	d := r.retry.MinBackoff
+	for i := 0; i < attempt && d > 0 && d <= maxBackoff; i++ {
+		if r.retry.MaxBackoff > 0 && d >= r.retry.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if r.retry.MaxBackoff > 0 && d > r.retry.MaxBackoff {
+		d = r.retry.MaxBackoff
+	}
+	if d > 0 {
+		// Pick a random delay in [d/2, d) so that concurrent clients spread out.
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) //nolint:gosec // Jitter does not need a secure source.
+	}
+
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > d {
+		d = apiErr.RetryAfter
+		if r.retry.MaxBackoff > 0 && d > r.retry.MaxBackoff {
+			d = r.retry.MaxBackoff
+		}
+	}
+
+	return d
+}
+
+// retryable reports whether a request that failed with err should be retried.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) {
+		return apiErr.RateLimited() || apiErr.ServerError()
+	}
+
+	return true
+}
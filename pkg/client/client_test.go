@@ -0,0 +1,334 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+func TestAPIError(t *testing.T) {
+	t.Parallel()
+
+	c := newTestPriceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("<html>slow down</html>"))
+	})
+
+	_, err := c.GetLatest(context.Background(), nil)
+
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("unexpected status %d", apiErr.StatusCode)
+	}
+	if apiErr.Endpoint != "latest" {
+		t.Errorf("unexpected endpoint %q", apiErr.Endpoint)
+	}
+	if apiErr.Body != "<html>slow down</html>" {
+		t.Errorf("unexpected body %q", apiErr.Body)
+	}
+	if apiErr.RetryAfter != 30*time.Second {
+		t.Errorf("unexpected retry after %v", apiErr.RetryAfter)
+	}
+	if !apiErr.RateLimited() || apiErr.ServerError() {
+		t.Error("expected rate limited error")
+	}
+}
+
+func TestGetReturnsBodyOnError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("maintenance"))
+	}))
+	t.Cleanup(srv.Close)
+
+	data, code, err := client.NewClient(srv.URL, srv.Client()).Get(context.Background(), "mapping", nil)
+
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || !apiErr.ServerError() {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status %d", code)
+	}
+	if string(data) != "maintenance" {
+		t.Errorf("unexpected body %q", data)
+	}
+}
+
+func newRetryClient(t *testing.T, handler http.HandlerFunc) *client.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return client.NewClient(srv.URL, srv.Client(), client.WithRetry(client.RetryConfig{
+		MaxRetries: 3,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	}))
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	c := newRetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	})
+
+	if _, _, err := c.Get(context.Background(), "latest", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected 3 calls, got %d", n)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		status int
+		calls  int32
+	}{
+		"server error": {status: http.StatusBadGateway, calls: 4},
+		"client error": {status: http.StatusNotFound, calls: 1},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+			c := newRetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tc.status)
+			})
+
+			_, _, err := c.Get(context.Background(), "latest", nil)
+			apiErr := &client.APIError{}
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status {
+				t.Fatalf("expected status %d, got %v", tc.status, err)
+			}
+			if n := calls.Load(); n != tc.calls {
+				t.Fatalf("expected %d calls, got %d", tc.calls, n)
+			}
+		})
+	}
+}
+
+func TestRetryAfterExceedsDeadline(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+
+	// Without MaxBackoff, Retry-After is honored in full.
+	c := client.NewClient(srv.URL, srv.Client(), client.WithRetry(client.RetryConfig{
+		MaxRetries: 3,
+		MinBackoff: time.Millisecond,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := c.Get(ctx, "latest", nil)
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || !apiErr.RateLimited() {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call, got %d", n)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("waited for Retry-After beyond the context deadline")
+	}
+}
+
+func TestNoRetryAfterCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls atomic.Int32
+	c := newRetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if _, _, err := c.Get(ctx, "latest", nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call, got %d", n)
+	}
+}
+
+func TestRetryAfterCappedByMaxBackoff(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	c := newRetryClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	start := time.Now()
+	_, _, err := c.Get(context.Background(), "latest", nil)
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || !apiErr.RateLimited() {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("expected 4 calls, got %d", n)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("waited for Retry-After beyond MaxBackoff")
+	}
+}
+
+func TestRetryManyAttempts(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	// Doubling MinBackoff 100 times would overflow without clamping.
+	c := client.NewClient(srv.URL, srv.Client(), client.WithRetry(client.RetryConfig{
+		MaxRetries: 100,
+		MinBackoff: time.Microsecond,
+		MaxBackoff: time.Millisecond,
+	}))
+
+	start := time.Now()
+	if _, _, err := c.Get(context.Background(), "latest", nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := calls.Load(); n != 101 {
+		t.Fatalf("expected 101 calls, got %d", n)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("backoff exceeded MaxBackoff")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		retry   client.RetryConfig
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		"first retry": {
+			retry:   client.RetryConfig{MinBackoff: time.Second, MaxBackoff: time.Minute},
+			attempt: 0,
+			min:     500 * time.Millisecond,
+			max:     time.Second,
+		},
+		"doubled": {
+			retry:   client.RetryConfig{MinBackoff: time.Second, MaxBackoff: time.Minute},
+			attempt: 3,
+			min:     4 * time.Second,
+			max:     8 * time.Second,
+		},
+		"capped": {
+			retry:   client.RetryConfig{MinBackoff: time.Second, MaxBackoff: time.Minute},
+			attempt: 100,
+			min:     30 * time.Second,
+			max:     time.Minute,
+		},
+		"no base delay": {
+			retry:   client.RetryConfig{MaxBackoff: time.Minute},
+			attempt: 0,
+			min:     0,
+			max:     0,
+		},
+		"no base delay later": {
+			retry:   client.RetryConfig{MaxBackoff: time.Minute},
+			attempt: 5,
+			min:     0,
+			max:     0,
+		},
+		"no cap": {
+			retry:   client.RetryConfig{MinBackoff: 10 * time.Second},
+			attempt: 29,
+			min:     10 * time.Second << 28,
+			max:     10 * time.Second << 29,
+		},
+		"no cap beyond overflow": {
+			retry:   client.RetryConfig{MinBackoff: 10 * time.Second},
+			attempt: 30,
+			min:     10 * time.Second << 28,
+			max:     math.MaxInt64,
+		},
+		"no cap many attempts": {
+			retry:   client.RetryConfig{MinBackoff: 10 * time.Second},
+			attempt: 1000,
+			min:     math.MaxInt64 / 4,
+			max:     math.MaxInt64,
+		},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := client.NewClient("http://localhost", http.DefaultClient, client.WithRetry(tc.retry))
+			for i := 0; i < 10; i++ {
+				if d := c.Backoff(tc.attempt, nil); d < tc.min || d > tc.max {
+					t.Fatalf("expected backoff in [%v, %v], got %v", tc.min, tc.max, d)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	err := &client.APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}
+
+	c := client.NewClient("http://localhost", http.DefaultClient, client.WithRetry(client.RetryConfig{}))
+	if d := c.Backoff(0, err); d != time.Hour {
+		t.Errorf("expected Retry-After without a base delay, got %v", d)
+	}
+
+	c = client.NewClient("http://localhost", http.DefaultClient, client.WithRetry(client.RetryConfig{
+		MaxBackoff: time.Minute,
+	}))
+	if d := c.Backoff(0, err); d != time.Minute {
+		t.Errorf("expected Retry-After capped by MaxBackoff, got %v", d)
+	}
+}
@@ -1,5 +1,7 @@
 package client
 
+import "time"
+
 type DataLatest struct {
 	Data map[string]ItemLatest `json:"data"`
 }
@@ -12,7 +14,13 @@ type ItemLatest struct {
 }
 
 type DataAvg struct {
-	Data map[string]ItemAvg `json:"data"`
+	Data      map[string]ItemAvg `json:"data"`
+	Timestamp int                `json:"timestamp"`
+}
+
+// Time returns the start of the window covered by the response.
+func (d *DataAvg) Time() time.Time {
+	return time.Unix(int64(d.Timestamp), 0)
 }
 
 type ItemAvg struct {
@@ -22,6 +30,16 @@ type ItemAvg struct {
 	LowPriceVolume  *int `json:"lowPriceVolume,omitempty"`
 }
 
+type DataTimeseries struct {
+	ItemID int              `json:"itemId"`
+	Data   []ItemTimeseries `json:"data"`
+}
+
+type ItemTimeseries struct {
+	Timestamp int `json:"timestamp"`
+	ItemAvg
+}
+
 type ItemMapping struct {
 	ID       int    `json:"id"`
 	Name     string `json:"name"`
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxErrorBodyLen is the maximum number of bytes of the response body kept in
+// an APIError.
+const maxErrorBodyLen = 256
+
+// APIError is returned when the API responds with a non-2xx status code.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Endpoint is the API endpoint that was requested, e.g. "latest".
+	Endpoint string
+	// Body is an excerpt of the response body.
+	Body string
+	// RetryAfter is the delay requested by the Retry-After header, or zero if
+	// the header was absent or invalid.
+	RetryAfter time.Duration
+}
+
+func newAPIError(endpoint string, resp *http.Response, body []byte) *APIError {
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   endpoint,
+		Body:       excerpt(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s: unexpected status %d %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+
+	return msg
+}
+
+// RateLimited reports whether the API rejected the request due to rate
+// limiting.
+func (e *APIError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// ServerError reports whether the API failed to handle the request.
+func (e *APIError) ServerError() bool {
+	return e.StatusCode >= http.StatusInternalServerError
+}
+
+func excerpt(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) <= maxErrorBodyLen {
+		return s
+	}
+	s = s[:maxErrorBodyLen]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s + "..."
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,8 @@
+package client
+
+import "time"
+
+// Backoff returns the delay before the given retry attempt.
+func (r *Client) Backoff(attempt int, err error) time.Duration {
+	return r.backoff(attempt, err)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "osrs"
+	subsystem = "ge"
+)
+
+// codeError is the code of requests that failed without a response.
+const codeError = "error"
+
+// Buckets of the request and decode duration histograms.
+var (
+	requestDurationBuckets = prometheus.DefBuckets
+	decodeDurationBuckets  = prometheus.ExponentialBuckets(0.0005, 2, 12) //nolint:gomnd // 0.5ms to ~1s.
+)
+
+// metrics instrument the requests sent by a Client.
+type metrics struct {
+	requestDuration *prometheus.HistogramVec
+	responses       *prometheus.CounterVec
+	responseBytes   *prometheus.CounterVec
+	decodeDuration  *prometheus.HistogramVec
+	items           *prometheus.GaugeVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "client_request_duration_seconds",
+			Help:      "Duration of requests to the API, including reading the response, by endpoint.",
+			Buckets:   requestDurationBuckets,
+		}, []string{"endpoint"}),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "client_responses_total",
+			Help:      "Number of API responses, by endpoint and status code or error.",
+		}, []string{"endpoint", "code"}),
+		responseBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "client_response_bytes_total",
+			Help:      "Number of bytes read from API responses, by endpoint.",
+		}, []string{"endpoint"}),
+		decodeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "client_decode_duration_seconds",
+			Help:      "Duration of decoding JSON responses, by endpoint.",
+			Buckets:   decodeDurationBuckets,
+		}, []string{"endpoint"}),
+		items: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "client_response_items",
+			Help:      "Number of items in the last successful response, by endpoint.",
+		}, []string{"endpoint"}),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.requestDuration,
+		m.responses,
+		m.responseBytes,
+		m.decodeDuration,
+		m.items,
+	}
+}
+
+// Describe implements [prometheus.Collector].
+func (r *Client) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range r.metrics.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements [prometheus.Collector].
+func (r *Client) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range r.metrics.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// Describe implements [prometheus.Collector].
+func (c *PriceClient) Describe(ch chan<- *prometheus.Desc) {
+	c.client.Describe(ch)
+}
+
+// Collect implements [prometheus.Collector].
+func (c *PriceClient) Collect(ch chan<- prometheus.Metric) {
+	c.client.Collect(ch)
+}
+
+func (m *metrics) observeDecode(endpoint string, start time.Time) {
+	m.decodeDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,85 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	body := `{"data":{"2":{"high":160,"highTime":1615734000,"low":155,"lowTime":1615734000},` +
+		`"6":{"high":180000,"highTime":1615734000,"low":175000,"lowTime":1615734000}}}`
+	c := newTestPriceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "0" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+
+	if _, err := c.GetLatest(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetLatest(context.Background(), url.Values{"id": {"0"}}); err == nil {
+		t.Fatal("expected error")
+	}
+	// A canceled request fails without a response.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetLatest(ctx, nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	expected := `
+# HELP osrs_ge_client_response_bytes_total Number of bytes read from API responses, by endpoint.
+# TYPE osrs_ge_client_response_bytes_total counter
+osrs_ge_client_response_bytes_total{endpoint="latest"} ` + strconv.Itoa(len(body)) + `
+# HELP osrs_ge_client_response_items Number of items in the last successful response, by endpoint.
+# TYPE osrs_ge_client_response_items gauge
+osrs_ge_client_response_items{endpoint="latest"} 2
+# HELP osrs_ge_client_responses_total Number of API responses, by endpoint and status code or error.
+# TYPE osrs_ge_client_responses_total counter
+osrs_ge_client_responses_total{code="200",endpoint="latest"} 1
+osrs_ge_client_responses_total{code="400",endpoint="latest"} 1
+osrs_ge_client_responses_total{code="error",endpoint="latest"} 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"osrs_ge_client_response_bytes_total",
+		"osrs_ge_client_response_items",
+		"osrs_ge_client_responses_total",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := testutil.CollectAndCount(c, "osrs_ge_client_request_duration_seconds"); n != 1 {
+		t.Errorf("expected 1 request duration series, got %d", n)
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != "osrs_ge_client_request_duration_seconds" {
+			continue
+		}
+		if n := mf.GetMetric()[0].GetHistogram().GetSampleCount(); n != 3 {
+			t.Errorf("expected 3 request durations, got %d", n)
+		}
+	}
+	if n := testutil.CollectAndCount(c, "osrs_ge_client_decode_duration_seconds"); n != 1 {
+		t.Errorf("expected 1 decode duration series, got %d", n)
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	window5m = 5 * time.Minute
+	window1h = time.Hour
+)
+
+// ErrInvalidOptions is returned when query options fail validation. No
+// request is sent in this case.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// LatestOptions are the query options accepted by the latest endpoint.
+type LatestOptions struct {
+	// ItemID limits the response to a single item. The zero value returns all
+	// items.
+	ItemID int
+}
+
+func (o LatestOptions) validate() error {
+	if o.ItemID < 0 {
+		return fmt.Errorf("%w: item id must not be negative, got %d", ErrInvalidOptions, o.ItemID)
+	}
+
+	return nil
+}
+
+func (o LatestOptions) values() url.Values {
+	params := url.Values{}
+	if o.ItemID != 0 {
+		params.Set("id", strconv.Itoa(o.ItemID))
+	}
+
+	return params
+}
+
+// AvgOptions are the query options accepted by the 5m and 1h endpoints.
+type AvgOptions struct {
+	// Timestamp selects the window starting at the given time. The zero value
+	// selects the most recent window.
+	Timestamp time.Time
+}
+
+// validate checks that the timestamp marks the start of a window of the given
+// length, which is the only form the API accepts.
+func (o AvgOptions) validate(window time.Duration) error {
+	if o.Timestamp.IsZero() {
+		return nil
+	}
+	if o.Timestamp.Unix() <= 0 {
+		return fmt.Errorf("%w: timestamp must be after the Unix epoch, got %v", ErrInvalidOptions, o.Timestamp)
+	}
+	if o.Timestamp.Unix()%int64(window.Seconds()) != 0 {
+		return fmt.Errorf("%w: timestamp %d is not aligned to a %v window",
+			ErrInvalidOptions, o.Timestamp.Unix(), window)
+	}
+	if o.Timestamp.After(time.Now()) {
+		return fmt.Errorf("%w: timestamp %v is in the future", ErrInvalidOptions, o.Timestamp)
+	}
+
+	return nil
+}
+
+func (o AvgOptions) values() url.Values {
+	params := url.Values{}
+	if !o.Timestamp.IsZero() {
+		params.Set("timestamp", strconv.FormatInt(o.Timestamp.Unix(), 10))
+	}
+
+	return params
+}
+
+// TimeseriesOptions are the query options accepted by the timeseries endpoint.
+type TimeseriesOptions struct {
+	// ItemID is the item to return data for. It is required.
+	ItemID int
+	// Timestep is the resolution of the returned data points. It is required.
+	Timestep Timestep
+}
+
+func (o TimeseriesOptions) validate() error {
+	if o.ItemID <= 0 {
+		return fmt.Errorf("%w: item id must be positive, got %d", ErrInvalidOptions, o.ItemID)
+	}
+	if !o.Timestep.valid() {
+		return fmt.Errorf("%w: invalid timestep %q", ErrInvalidOptions, o.Timestep)
+	}
+
+	return nil
+}
+
+func (o TimeseriesOptions) values() url.Values {
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(o.ItemID))
+	params.Set("timestep", string(o.Timestep))
+
+	return params
+}
@@ -6,70 +6,145 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
+// Timestep is the resolution of a timeseries.
+type Timestep string
+
+const (
+	Timestep5m  Timestep = "5m"
+	Timestep1h  Timestep = "1h"
+	Timestep6h  Timestep = "6h"
+	Timestep24h Timestep = "24h"
+)
+
+func (t Timestep) valid() bool {
+	switch t {
+	case Timestep5m, Timestep1h, Timestep6h, Timestep24h:
+		return true
+	default:
+		return false
+	}
+}
+
 type PriceClient struct {
 	client *Client
 }
 
-func NewOSRSPriceClient() *PriceClient {
+// NewPriceClient creates a PriceClient that sends requests using the given
+// Client.
+func NewPriceClient(client *Client) *PriceClient {
 	return &PriceClient{
-		client: NewClient("https://prices.runescape.wiki/api/v1/osrs", http.DefaultClient),
+		client: client,
 	}
 }
 
+// NewOSRSPriceClient creates a PriceClient for the OSRS Wiki prices API.
+func NewOSRSPriceClient(opts ...Option) *PriceClient {
+	return NewPriceClient(NewClient("https://prices.runescape.wiki/api/v1/osrs", http.DefaultClient, opts...))
+}
+
 func (c *PriceClient) GetLatest(ctx context.Context, params url.Values) (*DataLatest, error) {
 	data := &DataLatest{}
-	resp, _, err := c.client.Get(ctx, "latest", params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get latest: %w", err)
-	}
-	err = json.Unmarshal(resp, data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := c.get(ctx, "latest", params, data); err != nil {
+		return nil, err
 	}
+	c.client.metrics.items.WithLabelValues("latest").Set(float64(len(data.Data)))
 
 	return data, nil
 }
 
+// GetLatestWithOptions returns the latest prices using the given options.
+func (c *PriceClient) GetLatestWithOptions(ctx context.Context, opts LatestOptions) (*DataLatest, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	return c.GetLatest(ctx, opts.values())
+}
+
 func (c *PriceClient) Get5m(ctx context.Context, params url.Values) (*DataAvg, error) {
 	data := &DataAvg{}
-	resp, _, err := c.client.Get(ctx, "5m", params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get 5m: %w", err)
-	}
-	err = json.Unmarshal(resp, data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := c.get(ctx, "5m", params, data); err != nil {
+		return nil, err
 	}
+	c.client.metrics.items.WithLabelValues("5m").Set(float64(len(data.Data)))
 
 	return data, nil
 }
 
+// Get5mWithOptions returns 5m average prices using the given options.
+func (c *PriceClient) Get5mWithOptions(ctx context.Context, opts AvgOptions) (*DataAvg, error) {
+	if err := opts.validate(window5m); err != nil {
+		return nil, err
+	}
+
+	return c.Get5m(ctx, opts.values())
+}
+
 func (c *PriceClient) Get1h(ctx context.Context, params url.Values) (*DataAvg, error) {
 	data := &DataAvg{}
-	resp, _, err := c.client.Get(ctx, "1h", params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get 1h: %w", err)
-	}
-	err = json.Unmarshal(resp, data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := c.get(ctx, "1h", params, data); err != nil {
+		return nil, err
 	}
+	c.client.metrics.items.WithLabelValues("1h").Set(float64(len(data.Data)))
 
 	return data, nil
 }
 
+// Get1hWithOptions returns 1h average prices using the given options.
+func (c *PriceClient) Get1hWithOptions(ctx context.Context, opts AvgOptions) (*DataAvg, error) {
+	if err := opts.validate(window1h); err != nil {
+		return nil, err
+	}
+
+	return c.Get1h(ctx, opts.values())
+}
+
 func (c *PriceClient) GetMapping(ctx context.Context, params url.Values) ([]ItemMapping, error) {
 	data := []ItemMapping{}
-	resp, _, err := c.client.Get(ctx, "mapping", params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get mapping: %w", err)
+	if err := c.get(ctx, "mapping", params, &data); err != nil {
+		return nil, err
 	}
-	err = json.Unmarshal(resp, &data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	c.client.metrics.items.WithLabelValues("mapping").Set(float64(len(data)))
+
+	return data, nil
+}
+
+// GetTimeseries returns up to 365 data points for a single item at the given
+// timestep.
+func (c *PriceClient) GetTimeseries(ctx context.Context, itemID int, timestep Timestep) (*DataTimeseries, error) {
+	return c.GetTimeseriesWithOptions(ctx, TimeseriesOptions{ItemID: itemID, Timestep: timestep})
+}
+
+// GetTimeseriesWithOptions returns up to 365 data points for a single item
+// using the given options.
+func (c *PriceClient) GetTimeseriesWithOptions(ctx context.Context, opts TimeseriesOptions) (*DataTimeseries, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	data := &DataTimeseries{}
+	if err := c.get(ctx, "timeseries", opts.values(), data); err != nil {
+		return nil, err
 	}
+	c.client.metrics.items.WithLabelValues("timeseries").Set(float64(len(data.Data)))
 
 	return data, nil
 }
+
+// get sends a GET request for the given query and decodes the response into v.
+func (c *PriceClient) get(ctx context.Context, query string, params url.Values, v any) error {
+	resp, _, err := c.client.Get(ctx, query, params)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", query, err)
+	}
+
+	defer c.client.metrics.observeDecode(query, time.Now())
+	if err := json.Unmarshal(resp, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MacroPower/osrs_ge_exporter/pkg/client"
+)
+
+func newTestPriceClient(t *testing.T, handler http.HandlerFunc) *client.PriceClient {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return client.NewPriceClient(client.NewClient(srv.URL, srv.Client()))
+}
+
+func TestGetTimeseries(t *testing.T) {
+	t.Parallel()
+
+	c := newTestPriceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/timeseries" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("id"); got != "4151" {
+			t.Errorf("unexpected id %q", got)
+		}
+		if got := r.URL.Query().Get("timestep"); got != "1h" {
+			t.Errorf("unexpected timestep %q", got)
+		}
+		_, _ = w.Write([]byte(`{"data":[` +
+			`{"timestamp":1615734000,"avgHighPrice":2500000,"avgLowPrice":null,"highPriceVolume":5,"lowPriceVolume":0},` +
+			`{"timestamp":1615737600,"avgHighPrice":2510000,"avgLowPrice":2490000,"highPriceVolume":3,"lowPriceVolume":2}` +
+			`],"itemId":4151}`))
+	})
+
+	data, err := c.GetTimeseries(context.Background(), 4151, client.Timestep1h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.ItemID != 4151 {
+		t.Fatalf("expected item 4151, got %d", data.ItemID)
+	}
+	if len(data.Data) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(data.Data))
+	}
+	if data.Data[0].Timestamp != 1615734000 {
+		t.Fatalf("unexpected timestamp %d", data.Data[0].Timestamp)
+	}
+	if data.Data[0].AvgHighPrice == nil || *data.Data[0].AvgHighPrice != 2500000 {
+		t.Fatal("unexpected avgHighPrice")
+	}
+	if data.Data[0].AvgLowPrice != nil {
+		t.Fatal("expected nil avgLowPrice")
+	}
+}
+
+func TestGetTimeseriesInvalidTimestep(t *testing.T) {
+	t.Parallel()
+
+	c := newTestPriceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	})
+
+	if _, err := c.GetTimeseries(context.Background(), 4151, "2h"); !errors.Is(err, client.ErrInvalidOptions) {
+		t.Fatalf("expected invalid options error, got %v", err)
+	}
+}
+
+func TestGet5mWithOptions(t *testing.T) {
+	t.Parallel()
+
+	c := newTestPriceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/5m" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("timestamp"); got != "1615733400" {
+			t.Errorf("unexpected timestamp %q", got)
+		}
+		_, _ = w.Write([]byte(`{"data":{"2":{"avgHighPrice":160,"highPriceVolume":100,` +
+			`"avgLowPrice":155,"lowPriceVolume":200}},"timestamp":1615733400}`))
+	})
+
+	data, err := c.Get5mWithOptions(context.Background(), client.AvgOptions{
+		Timestamp: time.Unix(1615733400, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.Time().Equal(time.Unix(1615733400, 0)) {
+		t.Fatalf("unexpected time %v", data.Time())
+	}
+	if _, ok := data.Data["2"]; !ok {
+		t.Fatal("item not found")
+	}
+}
+
+func TestGetLatestWithOptions(t *testing.T) {
+	t.Parallel()
+
+	c := newTestPriceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("id"); got != "2" {
+			t.Errorf("unexpected id %q", got)
+		}
+		_, _ = w.Write([]byte(`{"data":{"2":{"high":160,"highTime":1615733400,"low":155,"lowTime":1615733300}}}`))
+	})
+
+	data, err := c.GetLatestWithOptions(context.Background(), client.LatestOptions{ItemID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item, ok := data.Data["2"]; !ok || item.High == nil || *item.High != 160 {
+		t.Fatal("unexpected item data")
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	c := newTestPriceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	})
+	ctx := context.Background()
+
+	tcs := map[string]func() error{
+		"negative item id": func() error {
+			_, err := c.GetLatestWithOptions(ctx, client.LatestOptions{ItemID: -1})
+
+			return err //nolint:wrapcheck
+		},
+		"unaligned 5m timestamp": func() error {
+			_, err := c.Get5mWithOptions(ctx, client.AvgOptions{Timestamp: time.Unix(1615733401, 0)})
+
+			return err //nolint:wrapcheck
+		},
+		"unaligned 1h timestamp": func() error {
+			_, err := c.Get1hWithOptions(ctx, client.AvgOptions{Timestamp: time.Unix(1615733400, 0)})
+
+			return err //nolint:wrapcheck
+		},
+		"future timestamp": func() error {
+			_, err := c.Get1hWithOptions(ctx, client.AvgOptions{Timestamp: time.Now().Add(48 * time.Hour).Truncate(time.Hour)})
+
+			return err //nolint:wrapcheck
+		},
+		"missing item id": func() error {
+			_, err := c.GetTimeseriesWithOptions(ctx, client.TimeseriesOptions{Timestep: client.Timestep5m})
+
+			return err //nolint:wrapcheck
+		},
+	}
+	for name, fn := range tcs {
+		if err := fn(); !errors.Is(err, client.ErrInvalidOptions) {
+			t.Errorf("%s: expected invalid options error, got %v", name, err)
+		}
+	}
+}